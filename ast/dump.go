@@ -0,0 +1,106 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// Dump writes an indented tree of node to w, one field per line.
+// node can be a single Stmt or Expr, or a slice of them.
+// It walks the nodes using reflection so it doesn't need updating
+// every time a node is added or changed.
+func Dump(w io.Writer, node any) error {
+	d := dumper{w: w}
+	d.dump(reflect.ValueOf(node), 0)
+	return d.err
+}
+
+type dumper struct {
+	w   io.Writer
+	err error
+}
+
+var tokPtrType = reflect.TypeFor[*token.Token]()
+
+func (d *dumper) printf(depth int, format string, args ...any) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, "%s"+format, append([]any{strings.Repeat("  ", depth)}, args...)...)
+}
+
+// dump expects the caller to have already written any label for v
+// so that the value can be written on the same line
+func (d *dumper) dump(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.printf(0, "nil\n")
+		return
+	}
+	if v.Type() == tokPtrType {
+		if v.IsNil() {
+			d.printf(0, "nil\n")
+			return
+		}
+		tok := v.Interface().(*token.Token)
+		d.printf(0, "%s %q (%d:%d)\n", tok.Kind, tok.Lexeme, tok.Line, tok.Col)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			d.printf(0, "nil\n")
+			return
+		}
+		if v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct {
+			d.dumpStruct(v.Elem(), depth)
+			return
+		}
+		d.dump(v.Elem(), depth)
+	case reflect.Struct:
+		d.dumpStruct(v, depth)
+	case reflect.Slice:
+		if v.Len() == 0 {
+			d.printf(0, "[]\n")
+			return
+		}
+		d.printf(0, "[%d]\n", v.Len())
+		for idx := range v.Len() {
+			d.printf(depth+1, "%d: ", idx)
+			d.dump(v.Index(idx), depth+1)
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			d.printf(0, "{}\n")
+			return
+		}
+		d.printf(0, "{%d}\n", v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			d.printf(depth+1, "Key: ")
+			d.dump(iter.Key(), depth+1)
+			d.printf(depth+1, "Value: ")
+			d.dump(iter.Value(), depth+1)
+		}
+	case reflect.String:
+		d.printf(0, "%q\n", v.String())
+	default:
+		d.printf(0, "%v\n", v.Interface())
+	}
+}
+
+func (d *dumper) dumpStruct(v reflect.Value, depth int) {
+	typ := v.Type()
+	d.printf(0, "%s\n", typ.Name())
+	for idx := range typ.NumField() {
+		field := typ.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+		d.printf(depth+1, "%s: ", field.Name)
+		d.dump(v.Field(idx), depth+1)
+	}
+}
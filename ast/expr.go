@@ -96,16 +96,23 @@ func (expr *Grouping) String() string {
 	return fmt.Sprintf("(group %s)", expr.Expression)
 }
 
+// HashPair is a single `key: value` entry in a HashLiteral
+type HashPair struct {
+	Key   Expr
+	Value Expr
+}
+
 type HashLiteral struct {
 	Brace *token.Token
-	Pairs map[Expr]Expr
+	// kept in source order so the ast can be printed back out faithfully
+	Pairs []HashPair
 }
 
 func (expr *HashLiteral) String() string {
 	var sb strings.Builder
 	sb.WriteString("({\n")
-	for k, v := range expr.Pairs {
-		sb.WriteString(fmt.Sprintf("   %s: %s,\n", k, v))
+	for _, pair := range expr.Pairs {
+		sb.WriteString(fmt.Sprintf("   %s: %s,\n", pair.Key, pair.Value))
 	}
 	sb.WriteString("})")
 	return sb.String()
@@ -11,6 +11,16 @@ type Stmt interface {
 	String() string
 }
 
+// BadStmt is a statement that failed to parse, it covers the tokens
+// from From to To that were skipped while recovering from the error
+type BadStmt struct {
+	From, To *token.Token
+}
+
+func (stmt *BadStmt) String() string {
+	return "(bad)"
+}
+
 type Block struct {
 	Statements []Stmt
 }
@@ -82,6 +92,49 @@ func (stmt *Function) String() string {
 	return sb.String()
 }
 
+// ForIn is `for (var x in iterable)` or `for (var k, v in iterable)`
+type ForIn struct {
+	Keyword *token.Token
+	// 1 or 2 loop variables
+	Vars     []*token.Token
+	Iterable Expr
+	Body     Stmt
+	// nil if the loop isn't labelled
+	Label *token.Token
+}
+
+func (stmt *ForIn) String() string {
+	names := make([]string, 0, len(stmt.Vars))
+	for _, v := range stmt.Vars {
+		names = append(names, v.Lexeme)
+	}
+	return fmt.Sprintf("(for-in %s %s %s)", strings.Join(names, ","), stmt.Iterable, stmt.Body)
+}
+
+// Import is `import "path" as name;` or `from "path" import a, b;`
+type Import struct {
+	// `import` or `from`
+	Keyword *token.Token
+	Path    *token.Token
+	// the name the module is bound to, nil for `from` imports
+	Name *token.Token
+	// the members a `from` import binds
+	Names []*token.Token
+}
+
+func (stmt *Import) String() string {
+	if stmt.Name != nil {
+		return fmt.Sprintf("(import %s %s)", stmt.Path.Lexeme, stmt.Name.Lexeme)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("(from %s", stmt.Path.Lexeme))
+	for _, name := range stmt.Names {
+		sb.WriteString(" " + name.Lexeme)
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
 type If struct {
 	Condition  Expr
 	ThenBranch Stmt
@@ -106,6 +159,8 @@ func (stmt *Print) String() string {
 type Control struct {
 	Keyword *token.Token
 	Value   Expr
+	// the loop `break` or `continue` goes to, nil for the innermost loop
+	Label *token.Token
 }
 
 func (stmt *Control) String() string {
@@ -115,13 +170,95 @@ func (stmt *Control) String() string {
 			return "(return)"
 		}
 		return fmt.Sprintf("(return %s)", stmt.Value)
-	case token.BREAK:
-		return "(break)"
+	case token.BREAK, token.CONTINUE:
+		if stmt.Label == nil {
+			return fmt.Sprintf("(%s)", stmt.Keyword.Lexeme)
+		}
+		return fmt.Sprintf("(%s %s)", stmt.Keyword.Lexeme, stmt.Label.Lexeme)
+	case token.THROW:
+		return fmt.Sprintf("(throw %s)", stmt.Value)
 	default:
 		panic("unreachable")
 	}
 }
 
+type Switch struct {
+	Keyword *token.Token
+	Value   Expr
+	Cases   []SwitchCase
+	// nil if there is no default case
+	Default []Stmt
+}
+
+// SwitchCase is a single `case a, b: ...` in a Switch
+type SwitchCase struct {
+	Keyword *token.Token
+	Values  []Expr
+	Body    []Stmt
+}
+
+func (stmt *Switch) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("(switch %s", stmt.Value))
+	for _, c := range stmt.Cases {
+		sb.WriteString(" (case")
+		for _, val := range c.Values {
+			sb.WriteString(" " + val.String())
+		}
+		for _, s := range c.Body {
+			sb.WriteString(" " + s.String())
+		}
+		sb.WriteByte(')')
+	}
+	if stmt.Default != nil {
+		sb.WriteString(" (default")
+		for _, s := range stmt.Default {
+			sb.WriteString(" " + s.String())
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+type Try struct {
+	Keyword *token.Token
+	Body    []Stmt
+	// the name the caught error is bound to, nil if it isn't bound
+	CatchName *token.Token
+	// nil if there is no catch clause
+	Catch []Stmt
+	// nil if there is no finally clause
+	Finally []Stmt
+}
+
+func (stmt *Try) String() string {
+	var sb strings.Builder
+	sb.WriteString("(try")
+	for _, s := range stmt.Body {
+		sb.WriteString(" " + s.String())
+	}
+	if stmt.Catch != nil {
+		sb.WriteString(" (catch")
+		if stmt.CatchName != nil {
+			sb.WriteString(" " + stmt.CatchName.Lexeme)
+		}
+		for _, s := range stmt.Catch {
+			sb.WriteString(" " + s.String())
+		}
+		sb.WriteByte(')')
+	}
+	if stmt.Finally != nil {
+		sb.WriteString(" (finally")
+		for _, s := range stmt.Finally {
+			sb.WriteString(" " + s.String())
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
 type Var struct {
 	Name        *token.Token
 	Initializer Expr
@@ -136,10 +273,20 @@ func (stmt *Var) String() string {
 }
 
 type While struct {
+	// `while` or `for`
+	Keyword   *token.Token
 	Condition Expr
 	Body      Stmt
+	// the increment of a `for` loop, it is run after the body and after
+	// `continue`. nil if there isn't one
+	Increment Expr
+	// nil if the loop isn't labelled
+	Label *token.Token
 }
 
 func (stmt *While) String() string {
-	return fmt.Sprintf("(while %s %s)", stmt.Condition, stmt.Body)
+	if stmt.Increment == nil {
+		return fmt.Sprintf("(while %s %s)", stmt.Condition, stmt.Body)
+	}
+	return fmt.Sprintf("(while %s %s %s)", stmt.Condition, stmt.Body, stmt.Increment)
 }
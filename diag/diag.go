@@ -0,0 +1,193 @@
+// Package diag collects and renders the diagnostics reported by every phase
+// of the interpreter: the lexer, parser, resolver and the interpreter itself.
+package diag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+type Severity byte
+
+const (
+	SEV_ERROR Severity = iota
+	SEV_WARNING
+	SEV_NOTE
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SEV_ERROR:
+		return "error"
+	case SEV_WARNING:
+		return "warning"
+	case SEV_NOTE:
+		return "note"
+	default:
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+}
+
+// Diagnostic is a single problem found in a program.
+// Codes start with the phase that found them:
+// L for the lexer, P for the parser, S for the resolver and R for runtime errors
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	// the span the diagnostic is about, can be nil if it isn't about any
+	// particular part of the source
+	Tok  *token.Token
+	Msg  string
+	Help []string
+	// the calls that were in progress when a runtime error happened,
+	// outermost first
+	Trace []Frame
+}
+
+// Frame is a call in the traceback of a runtime error
+type Frame struct {
+	// the name of the function the frame is running
+	Name string
+	// where the function was when the error happened, either the call
+	// to the next frame or the error itself. it can be nil
+	Tok *token.Token
+}
+
+// repeats of the same frame past this are collapsed into one line,
+// so that deep recursion doesn't print thousands of lines
+const maxRepeats = 3
+
+func (d *Diagnostic) Error() string {
+	if d.Tok == nil {
+		return d.Msg
+	}
+	return fmt.Sprintf("%s: %s", d.Tok.Pos(), d.Msg)
+}
+
+// Render writes d to w with the source line it points at, for example:
+//
+//	error[P0001]: Expect ';' after value
+//	 --> main.lox:2:10
+//	  |
+//	2 | print a b;
+//	  |         ^
+//	  = help: ...
+//
+// if d has a traceback it comes after that, like python's:
+//
+//	Traceback (most recent call last):
+//	  File "main.lox", line 5, in <script>
+//	    foo();
+//	  File "main.lox", line 2, in foo
+//	    print a b;
+func (d *Diagnostic) Render(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString(d.Severity.String())
+	if d.Code != "" {
+		fmt.Fprintf(&sb, "[%s]", d.Code)
+	}
+	fmt.Fprintf(&sb, ": %s\n", d.Msg)
+
+	gutter := ""
+	if d.Tok != nil {
+		lineNo := fmt.Sprint(d.Tok.Line)
+		gutter = strings.Repeat(" ", len(lineNo))
+		fmt.Fprintf(&sb, "%s--> %s\n", gutter, d.Tok.Pos())
+		if underline := d.Tok.Underline(); underline != "" {
+			fmt.Fprintf(&sb, "%s |\n", gutter)
+			fmt.Fprintf(&sb, "%s | %s\n", lineNo, d.Tok.File.LineText(d.Tok.Line))
+			fmt.Fprintf(&sb, "%s | %s\n", gutter, underline)
+		}
+	}
+	for _, help := range d.Help {
+		fmt.Fprintf(&sb, "%s = help: %s\n", gutter, help)
+	}
+	if len(d.Trace) != 0 {
+		writeTrace(&sb, d.Trace)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Traceback returns trace written the same way Render writes it
+func Traceback(trace []Frame) string {
+	var sb strings.Builder
+	writeTrace(&sb, trace)
+	return sb.String()
+}
+
+func writeTrace(sb *strings.Builder, trace []Frame) {
+	sb.WriteString("Traceback (most recent call last):\n")
+	repeats := 0
+	for idx, fr := range trace {
+		if idx > 0 && fr == trace[idx-1] {
+			repeats++
+			if repeats >= maxRepeats {
+				continue
+			}
+		} else {
+			writeRepeats(sb, repeats)
+			repeats = 0
+		}
+		if fr.Tok == nil {
+			fmt.Fprintf(sb, "  in %s\n", fr.Name)
+			continue
+		}
+		file := "<script>"
+		if fr.Tok.File != nil && fr.Tok.File.Name != "" {
+			file = fr.Tok.File.Name
+		}
+		fmt.Fprintf(sb, "  File \"%s\", line %d, in %s\n", file, fr.Tok.Line, fr.Name)
+		if fr.Tok.File != nil {
+			if line := strings.TrimSpace(fr.Tok.File.LineText(fr.Tok.Line)); line != "" {
+				fmt.Fprintf(sb, "    %s\n", line)
+			}
+		}
+	}
+	writeRepeats(sb, repeats)
+}
+
+func writeRepeats(sb *strings.Builder, repeats int) {
+	if repeats >= maxRepeats {
+		fmt.Fprintf(sb, "  [Previous line repeated %d more times]\n", repeats-maxRepeats+1)
+	}
+}
+
+// Reporter is where the phases send their diagnostics
+type Reporter struct {
+	// diagnostics are rendered to Out as they are reported,
+	// if it is nil they are only collected
+	Out   io.Writer
+	Diags []*Diagnostic
+}
+
+func NewReporter(out io.Writer) *Reporter {
+	return &Reporter{out, make([]*Diagnostic, 0)}
+}
+
+// Report records d and renders it to Out. d is returned so that it
+// can be used as an error
+func (r *Reporter) Report(d *Diagnostic) *Diagnostic {
+	r.Diags = append(r.Diags, d)
+	if r.Out != nil {
+		_ = d.Render(r.Out)
+	}
+	return d
+}
+
+// HadErr reports whether any errors have been reported since the last Reset
+func (r *Reporter) HadErr() bool {
+	for _, d := range r.Diags {
+		if d.Severity == SEV_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reporter) Reset() {
+	r.Diags = r.Diags[:0]
+}
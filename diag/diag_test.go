@@ -0,0 +1,88 @@
+package diag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+func TestTraceback(t *testing.T) {
+	file := &token.File{Name: "main.lox", Src: "f(3);\nfun f(n) {\n  f(n - 1);\n  g();\n}\n"}
+	tok := func(line int) *token.Token {
+		return &token.Token{Kind: token.IDENTIFIER, Lexeme: "f", Line: line, Col: 1, File: file}
+	}
+	script, call, other := Frame{"<script>", tok(1)}, Frame{"f", tok(3)}, Frame{"f", tok(4)}
+	// repeats returns n copies of fr
+	repeats := func(fr Frame, n int) []Frame {
+		frames := make([]Frame, n)
+		for i := range frames {
+			frames[i] = fr
+		}
+		return frames
+	}
+	const (
+		header    = "Traceback (most recent call last):\n"
+		scriptFr  = "  File \"main.lox\", line 1, in <script>\n    f(3);\n"
+		callFr    = "  File \"main.lox\", line 3, in f\n    f(n - 1);\n"
+		otherFr   = "  File \"main.lox\", line 4, in f\n    g();\n"
+		repeated2 = "  [Previous line repeated 2 more times]\n"
+	)
+	tests := []struct {
+		name  string
+		trace []Frame
+		want  string
+	}{
+		{"no repeats", []Frame{script, call, other}, header + scriptFr + callFr + otherFr},
+		{"3 repeats are printed", append([]Frame{script}, repeats(call, 3)...),
+			header + scriptFr + callFr + callFr + callFr},
+		{"more are collapsed", append([]Frame{script}, repeats(call, 5)...),
+			header + scriptFr + callFr + callFr + callFr + repeated2},
+		{"frames after a collapsed run", append(append([]Frame{script}, repeats(call, 5)...), other),
+			header + scriptFr + callFr + callFr + callFr + repeated2 + otherFr},
+		{"runs are counted separately", append(append(repeats(call, 5), other), repeats(call, 5)...),
+			header + strings.Repeat(callFr, 3) + repeated2 + otherFr + strings.Repeat(callFr, 3) + repeated2},
+		{"frames without a position", []Frame{{"<script>", nil}, {"native", nil}},
+			header + "  in <script>\n  in native\n"},
+		{"file without a name", []Frame{{"f", &token.Token{Line: 2, File: &token.File{Src: "\n  x;\n"}}}},
+			header + "  File \"<script>\", line 2, in f\n    x;\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Traceback(tt.trace); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTraceback(t *testing.T) {
+	file := &token.File{Name: "main.lox", Src: "fun f() {\n  return nil.x;\n}\nf();\n"}
+	errTok := &token.Token{Kind: token.IDENTIFIER, Lexeme: "x", Line: 2, Col: 14, File: file}
+	callTok := &token.Token{Kind: token.IDENTIFIER, Lexeme: "f", Line: 4, Col: 1, File: file}
+	d := &Diagnostic{
+		Severity: SEV_ERROR,
+		Code:     "R0001",
+		Tok:      errTok,
+		Msg:      "Only instances have properties",
+		Trace:    []Frame{{"<script>", callTok}, {"f", errTok}},
+	}
+	var sb strings.Builder
+	if err := d.Render(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `error[R0001]: Only instances have properties
+ --> main.lox:2:14
+  |
+2 |   return nil.x;
+  |              ^
+Traceback (most recent call last):
+  File "main.lox", line 4, in <script>
+    f();
+  File "main.lox", line 2, in f
+    return nil.x;
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
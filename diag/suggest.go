@@ -0,0 +1,52 @@
+package diag
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Suggest returns a "did you mean" help message for the candidate closest
+// to name, or "" if none of them are close enough to be a likely typo
+func Suggest(name string, candidates []string) string {
+	best, bestDist := "", max(1, utf8.RuneCountInString(name)/3)+1
+	for _, cand := range candidates {
+		if cand == name {
+			continue
+		}
+		if dist := editDistance(name, cand); dist < bestDist || (dist == bestDist && cand < best) {
+			best, bestDist = cand, dist
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("did you mean `%s`?", best)
+}
+
+// editDistance returns the number of single character insertions, deletions,
+// substitutions, and swaps of adjacent characters needed to turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// only the last 2 rows are needed to fill in the next one
+	prv2 := make([]int, len(rb)+1)
+	prv := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prv {
+		prv[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prv[j]+1, cur[j-1]+1, prv[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prv2[j-2]+1)
+			}
+		}
+		prv2, prv, cur = prv, cur, prv2
+	}
+	return prv[len(rb)]
+}
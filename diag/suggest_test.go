@@ -0,0 +1,65 @@
+package diag
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"same", "same", 0},
+		{"cat", "cut", 1},
+		{"cat", "cats", 1},
+		{"cats", "cat", 1},
+		{"teh", "the", 1},
+		{"abcd", "badc", 2},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+		{"ab", "ba", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name       string
+		typo       string
+		candidates []string
+		want       string
+	}{
+		{"no candidates", "x", nil, ""},
+		{"empty candidates", "x", []string{}, ""},
+		{"exact match isn't suggested", "print", []string{"print"}, ""},
+		{"one edit", "pritn", []string{"print", "clock"}, "did you mean `print`?"},
+		{"closest wins", "conter", []string{"counter", "center2", "count"}, "did you mean `counter`?"},
+		{"ties go to the first in order", "bat", []string{"cat", "hat", "rat"}, "did you mean `cat`?"},
+		{"ties don't depend on order", "bat", []string{"rat", "hat", "cat"}, "did you mean `cat`?"},
+		// names shorter than 6 runes allow 1 edit
+		{"short name, 1 edit", "ab", []string{"abc"}, "did you mean `abc`?"},
+		{"short name, 2 edits", "ab", []string{"abcd"}, ""},
+		{"5 runes, 2 edits", "abcde", []string{"abcdefg"}, ""},
+		// then 1 more edit for every 3 runes
+		{"6 runes, 2 edits", "abcdef", []string{"abcdefgh"}, "did you mean `abcdefgh`?"},
+		{"6 runes, 3 edits", "abcdef", []string{"abcdefghi"}, ""},
+		{"9 runes, 3 edits", "abcdefghi", []string{"abcdefghijkl"}, "did you mean `abcdefghijkl`?"},
+		{"runes not bytes", "héllo", []string{"hallo!"}, ""},
+		{"empty name", "", []string{"a", "bb"}, "did you mean `a`?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Suggest(tt.typo, tt.candidates); got != tt.want {
+				t.Errorf("Suggest(%q, %q) = %q, want %q", tt.typo, tt.candidates, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// diff returns a unified diff of a and b, or nil if they are the same
+func diff(name string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	x, y := splitLines(a), splitLines(b)
+	edits := lcsEdits(x, y)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s.orig\n+++ %s\n", name, name)
+	for start := 0; start < len(edits); {
+		// find the next change and the hunk around it
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		lo := max(start-diffContext, 0)
+		hi := start
+		for gap := 0; hi < len(edits) && gap <= 2*diffContext; hi++ {
+			if edits[hi].op == ' ' {
+				gap++
+			} else {
+				gap = 0
+			}
+		}
+		// trim the context after the last change
+		for hi > start && edits[hi-1].op == ' ' {
+			hi--
+		}
+		hi = min(hi+diffContext, len(edits))
+
+		oldStart, newStart, oldLen, newLen := edits[lo].x+1, edits[lo].y+1, 0, 0
+		for _, e := range edits[lo:hi] {
+			if e.op != '+' {
+				oldLen++
+			}
+			if e.op != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, e := range edits[lo:hi] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			out.WriteByte('\n')
+		}
+		start = hi
+	}
+	return out.Bytes()
+}
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+	// index of the line in the old and new text
+	x, y int
+}
+
+func splitLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// lcsEdits returns the edits that turn x into y using the longest common
+// subsequence of lines. the common prefix and suffix are skipped first
+// as formatting changes are usually small compared to the whole file
+func lcsEdits(x, y []string) []edit {
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+	mx, my := x[pre:len(x)-suf], y[pre:len(y)-suf]
+
+	// lcs[i][j] is the lcs length of mx[i:] and my[j:]
+	lcs := make([][]int, len(mx)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(my)+1)
+	}
+	for i := len(mx) - 1; i >= 0; i-- {
+		for j := len(my) - 1; j >= 0; j-- {
+			if mx[i] == my[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(x)+len(y))
+	for i := range pre {
+		edits = append(edits, edit{' ', x[i], i, i})
+	}
+	i, j := 0, 0
+	for i < len(mx) || j < len(my) {
+		switch {
+		case i < len(mx) && j < len(my) && mx[i] == my[j]:
+			edits = append(edits, edit{' ', mx[i], pre + i, pre + j})
+			i++
+			j++
+		case i < len(mx) && (j == len(my) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', mx[i], pre + i, pre + j})
+			i++
+		default:
+			edits = append(edits, edit{'+', my[j], pre + i, pre + j})
+			j++
+		}
+	}
+	for k := range suf {
+		xi, yi := len(x)-suf+k, len(y)-suf+k
+		edits = append(edits, edit{' ', x[xi], xi, yi})
+	}
+	return edits
+}
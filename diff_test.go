@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	// lines returns the lines 1 to n, with the lines in change replaced
+	lines := func(n int, change map[int]string) string {
+		var sb strings.Builder
+		for i := 1; i <= n; i++ {
+			if s, ok := change[i]; ok {
+				sb.WriteString(s)
+			} else {
+				sb.WriteString(strconv.Itoa(i))
+			}
+			sb.WriteByte('\n')
+		}
+		return sb.String()
+	}
+	tests := []struct {
+		name, a, b, want string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{"added", "a\nc\n", "a\nb\nc\n", "@@ -1,2 +1,3 @@\n a\n+b\n c\n"},
+		{"removed", "a\nb\nc\n", "a\nc\n", "@@ -1,3 +1,2 @@\n a\n-b\n c\n"},
+		{"changed", "a\nb\nc\n", "a\nB\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"from empty", "", "a\n", "@@ -1,0 +1,1 @@\n+a\n"},
+		{
+			"separate hunks",
+			lines(20, map[int]string{2: "old"}),
+			lines(20, map[int]string{2: "new", 19: "new"}),
+			"@@ -1,5 +1,5 @@\n 1\n-old\n+new\n 3\n 4\n 5\n" +
+				"@@ -16,5 +16,5 @@\n 16\n 17\n 18\n-19\n+new\n 20\n",
+		},
+		{
+			"close changes share a hunk",
+			lines(10, nil),
+			lines(10, map[int]string{3: "new", 8: "new"}),
+			"@@ -1,10 +1,10 @@\n 1\n 2\n-3\n+new\n 4\n 5\n 6\n 7\n-8\n+new\n 9\n 10\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(diff("f.lox", []byte(tt.a), []byte(tt.b)))
+			want := tt.want
+			if want != "" {
+				want = "--- f.lox.orig\n+++ f.lox\n" + want
+			}
+			if got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
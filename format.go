@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/format"
+)
+
+// runFmt runs `gojlox fmt` and returns the exit code
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write the result to the source file instead of stdout")
+	showDiff := flags.Bool("d", false, "print diffs instead of the formatted source, exits with 1 if any file isn't formatted")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: gojlox fmt [-w] [-d] [path ...]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "gojlox fmt: can't use -w on standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		changed, err := formatFile(stdout, stderr, "<stdin>", src, false, *showDiff)
+		if err != nil {
+			return 2
+		}
+		if changed && *showDiff {
+			return 1
+		}
+		return 0
+	}
+
+	code := 0
+	for _, root := range flags.Args() {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// files that are named explicitly are formatted whatever their extension
+			if d.IsDir() || (filepath.Ext(path) != ".lox" && path != root) {
+				return nil
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			changed, err := formatFile(stdout, stderr, path, src, *write, *showDiff)
+			if err != nil {
+				code = 2
+				return nil
+			}
+			if changed && *showDiff && code == 0 {
+				code = 1
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			code = 2
+		}
+	}
+	return code
+}
+
+// formatFile formats a single file and reports whether it changed
+func formatFile(stdout, stderr io.Writer, path string, src []byte, write, showDiff bool) (bool, error) {
+	res, err := format.Source(src)
+	if err != nil {
+		for line := range strings.Lines(err.Error()) {
+			fmt.Fprintf(stderr, "%s:%s", path, line)
+		}
+		fmt.Fprintln(stderr)
+		return false, err
+	}
+	changed := !bytes.Equal(src, res)
+	if showDiff {
+		stdout.Write(diff(path, src, res))
+	}
+	if write {
+		if changed {
+			return true, os.WriteFile(path, res, 0o644)
+		}
+		return false, nil
+	}
+	if !showDiff {
+		stdout.Write(res)
+	}
+	return changed, nil
+}
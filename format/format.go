@@ -0,0 +1,404 @@
+// Package format implements canonical formatting of Lox source code.
+//
+// The formatter works on the token stream of a program that has already been
+// checked by the parser. Working on tokens rather than on the ast means that
+// comments, blank lines and sugar like `for` loops and `+=` are written back
+// exactly as they were in the source, only the whitespace between tokens is
+// changed.
+package format
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/lexer"
+	"github.com/Subarctic2796/gojlox/parser"
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+const indentStr = "    "
+
+// Source formats the Lox program in src and returns the result.
+// src must be a valid program, if it isn't the lexer or parser errors are
+// returned, one per line, and nothing is formatted.
+func Source(src []byte) ([]byte, error) {
+	diags := diag.NewReporter(nil)
+	lex := scanner.NewLexer(string(src), diags)
+	lex.KeepComments = true
+	toks, lexErr := lex.ScanTokens()
+	code := make([]token.Token, 0, len(toks))
+	for _, tok := range toks {
+		if tok.Kind != token.COMMENT {
+			code = append(code, tok)
+		}
+	}
+	if _, err := parser.NewParser(code, diags).Parse(); err != nil || lexErr != nil {
+		return nil, diagErrs(diags)
+	}
+	f := &formatter{toks: toks}
+	f.format()
+	return f.out, nil
+}
+
+func diagErrs(diags *diag.Reporter) error {
+	errs := make([]error, 0, len(diags.Diags))
+	for _, d := range diags.Diags {
+		errs = append(errs, d)
+	}
+	return errors.Join(errs...)
+}
+
+// sep is the whitespace that is written before the next token
+type sep byte
+
+const (
+	sep_NONE sep = iota
+	sep_SPACE
+	sep_NEWLINE
+	sep_BLANK
+)
+
+// frame is an open bracket that hasn't been closed yet
+type frame struct {
+	open *token.Token
+	// `{` that starts a block rather than a hashmap
+	block bool
+	// block with nothing inside it, written as `{}`
+	empty bool
+	// array or hashmap literal that is written one element per line
+	multiline bool
+	// `(` after `if`, `while`, `for`, or `switch`
+	header bool
+	// `(` after `switch`, or the `{` of a switch's body
+	switch_ bool
+	// `{` of a switch's body once its first case has started, the lines
+	// after `case ...:` are indented one more than the case
+	inCase bool
+	// `{` of a switch's body between `case` or `default` and its `:`, other
+	// colons in the body, like a loop's label, don't start a case
+	caseHeader bool
+	// `(` after `for`, `;` doesn't end the line inside these
+	forClause bool
+	// `(` of a lambda's parameters, or `{` of a lambda's body
+	lambda bool
+	// `[` of an index or slice expression
+	index bool
+}
+
+type formatter struct {
+	toks  []token.Token
+	out   []byte
+	stack []frame
+	// frame that was closed by prev, only meaningful if prev is a closing bracket
+	closed frame
+	indent int
+	sep    sep
+	// last token written that isn't a comment
+	prev *token.Token
+	// where prev ends in out
+	prevEnd int
+	// last token written, including comments
+	last *token.Token
+	// set after a lambda's parameters, so the next `{` is the lambda's body
+	lambdaBody bool
+}
+
+// lines returns the first and last line tok is on
+func lines(tok *token.Token) (int, int) {
+	return tok.Line, tok.Line + strings.Count(tok.Lexeme, "\n")
+}
+
+func (f *formatter) format() {
+	for idx := range f.toks {
+		tok := &f.toks[idx]
+		switch tok.Kind {
+		case token.EOF:
+			if len(f.out) != 0 {
+				f.out = append(f.out, '\n')
+			}
+			return
+		case token.COMMENT:
+			f.comment(tok)
+		default:
+			next, adjacent := f.nextCode(idx)
+			f.token(tok, next, adjacent)
+		}
+	}
+}
+
+// nextCode returns the next token after idx that isn't a comment,
+// and whether there weren't any comments between them
+func (f *formatter) nextCode(idx int) (*token.Token, bool) {
+	for i := idx + 1; i < len(f.toks); i++ {
+		if f.toks[i].Kind != token.COMMENT {
+			return &f.toks[i], i == idx+1
+		}
+	}
+	return &f.toks[len(f.toks)-1], false
+}
+
+func (f *formatter) top() *frame {
+	if len(f.stack) == 0 {
+		return &frame{}
+	}
+	return &f.stack[len(f.stack)-1]
+}
+
+func (f *formatter) push(fr frame) { f.stack = append(f.stack, fr) }
+
+func (f *formatter) pop() frame {
+	fr := *f.top()
+	if len(f.stack) != 0 {
+		f.stack = f.stack[:len(f.stack)-1]
+	}
+	return fr
+}
+
+func (f *formatter) write(tok *token.Token, lexeme string) {
+	if f.last != nil {
+		if f.sep == sep_NEWLINE && f.last.Kind != token.LBRACE && tok.Kind != token.RBRACE {
+			// keep at most one blank line between statements
+			_, lastEnd := lines(f.last)
+			if first, _ := lines(tok); first-lastEnd > 1 {
+				f.sep = sep_BLANK
+			}
+		}
+		switch f.sep {
+		case sep_SPACE:
+			f.out = append(f.out, ' ')
+		case sep_NEWLINE, sep_BLANK:
+			f.out = append(f.out, '\n')
+			if f.sep == sep_BLANK {
+				f.out = append(f.out, '\n')
+			}
+			for range f.indent {
+				f.out = append(f.out, indentStr...)
+			}
+		}
+	}
+	f.out = append(f.out, lexeme...)
+	f.last = tok
+	f.sep = sep_NONE
+}
+
+func (f *formatter) comment(tok *token.Token) {
+	lexeme := tok.Lexeme
+	isLine := strings.HasPrefix(lexeme, "//")
+	if isLine {
+		lexeme = strings.TrimRight(lexeme, " \t\r")
+	}
+	first, _ := lines(tok)
+	if f.last != nil {
+		if _, lastEnd := lines(f.last); first == lastEnd {
+			// trailing comment, it stays on the line of the code before it
+			pending := f.sep
+			f.sep = sep_SPACE
+			f.write(tok, lexeme)
+			f.sep = max(pending, sep_SPACE)
+			if isLine {
+				f.sep = max(pending, sep_NEWLINE)
+			}
+			return
+		}
+		f.sep = max(f.sep, sep_NEWLINE)
+	}
+	f.write(tok, lexeme)
+	f.sep = sep_NEWLINE
+}
+
+// valueEnd reports whether prev ends an operand, in which case a following
+// `(` or `[` is a call or an index, and a following `-` is binary
+func (f *formatter) valueEnd() bool {
+	if f.prev == nil {
+		return false
+	}
+	switch f.prev.Kind {
+	case token.IDENTIFIER, token.STRING, token.NUMBER, token.TRUE, token.FALSE,
+		token.NIL, token.THIS, token.RSQR:
+		return true
+	case token.RPAREN:
+		return !f.closed.header
+	case token.RBRACE:
+		return !f.closed.block || f.closed.lambda
+	}
+	return false
+}
+
+// isBlock reports whether a `{` starts a block or class body
+// rather than a hashmap literal
+func (f *formatter) isBlock() bool {
+	if f.prev == nil {
+		return true
+	}
+	switch f.prev.Kind {
+	case token.RPAREN, token.ELSE, token.IDENTIFIER, token.SEMICOLON, token.LBRACE, token.RBRACE,
+		token.TRY, token.CATCH, token.FINALLY:
+		return true
+	case token.COLON:
+		// the start of a case's body
+		return f.top().switch_
+	}
+	return false
+}
+
+// before returns the whitespace tok wants between it and prev
+func (f *formatter) before(tok *token.Token) sep {
+	if f.prev == nil {
+		return sep_NONE
+	}
+	switch tok.Kind {
+	case token.RPAREN, token.RSQR, token.RBRACE, token.COMMA, token.SEMICOLON, token.DOT, token.COLON:
+		return sep_NONE
+	case token.LPAREN:
+		if f.valueEnd() || f.prev.Kind == token.FUN {
+			return sep_NONE
+		}
+	case token.LSQR:
+		if f.valueEnd() {
+			return sep_NONE
+		}
+	}
+	switch f.prev.Kind {
+	case token.LPAREN, token.LSQR, token.DOT:
+		return sep_NONE
+	}
+	return sep_SPACE
+}
+
+func (f *formatter) token(tok, next *token.Token, adjacent bool) {
+	lexeme := tok.Lexeme
+	after := sep_SPACE
+	// opening brackets indent the lines after them, not their own line
+	indent := 0
+	switch tok.Kind {
+	case token.LBRACE:
+		if f.isBlock() {
+			fr := frame{open: tok, block: true, lambda: f.lambdaBody}
+			fr.switch_ = f.prev != nil && f.prev.Kind == token.RPAREN && f.closed.switch_
+			f.lambdaBody = false
+			fr.empty = next.Kind == token.RBRACE && adjacent
+			f.push(fr)
+			if !fr.empty {
+				indent = 1
+				after = sep_NEWLINE
+			} else {
+				after = sep_NONE
+			}
+		} else {
+			after, indent = f.openLiteral(tok, next)
+		}
+	case token.LSQR:
+		if f.valueEnd() {
+			f.push(frame{open: tok, index: true})
+			after = sep_NONE
+		} else {
+			after, indent = f.openLiteral(tok, next)
+		}
+	case token.LPAREN:
+		f.push(frame{
+			open:      tok,
+			header:    f.prev != nil && slices.Contains([]token.TokenType{token.IF, token.WHILE, token.FOR, token.SWITCH}, f.prev.Kind),
+			switch_:   f.prev != nil && f.prev.Kind == token.SWITCH,
+			forClause: f.prev != nil && f.prev.Kind == token.FOR,
+			lambda:    f.prev != nil && f.prev.Kind == token.FUN,
+		})
+		after = sep_NONE
+	case token.RPAREN:
+		f.closed = f.pop()
+		f.lambdaBody = f.closed.lambda
+	case token.RBRACE, token.RSQR:
+		fr := f.pop()
+		f.closed = fr
+		switch {
+		case fr.block:
+			if fr.inCase {
+				f.indent--
+			}
+			if fr.empty {
+				f.sep = sep_NONE
+			} else {
+				f.indent--
+				f.sep = sep_NEWLINE
+			}
+			// lambdas are expressions so the rest of the line carries on
+			if !fr.lambda && !slices.Contains([]token.TokenType{token.ELSE, token.CATCH, token.FINALLY}, next.Kind) {
+				after = sep_NEWLINE
+			}
+		case fr.multiline:
+			if f.prev.Kind != token.COMMA {
+				f.out = slices.Insert(f.out, f.prevEnd, ',')
+			}
+			f.indent--
+			f.sep = sep_NEWLINE
+		}
+	case token.COMMA:
+		top := f.top()
+		if !top.multiline && (next.Kind == token.RSQR || next.Kind == token.RBRACE) {
+			// drop trailing commas in literals that fit on one line
+			return
+		}
+		if top.multiline {
+			after = sep_NEWLINE
+		}
+	case token.SEMICOLON:
+		if !f.top().forClause {
+			after = sep_NEWLINE
+		}
+	case token.DOT:
+		after = sep_NONE
+	case token.CASE, token.DEFAULT:
+		if top := f.top(); top.switch_ {
+			if top.inCase {
+				// the case before it is over
+				f.indent--
+				f.sep = sep_NEWLINE
+			}
+			top.caseHeader = true
+		}
+	case token.COLON:
+		if top := f.top(); top.index {
+			after = sep_NONE
+		} else if top.switch_ && top.caseHeader {
+			// end of `case ...:` or `default:`
+			top.caseHeader, top.inCase = false, true
+			after = sep_NEWLINE
+			indent = 1
+		}
+	case token.MINUS, token.BANG:
+		if !f.valueEnd() {
+			// unary operator
+			after = sep_NONE
+		}
+	}
+	if f.sep < sep_NEWLINE {
+		f.sep = min(f.sep, f.before(tok))
+		if f.prev == nil {
+			f.sep = sep_NONE
+		}
+	}
+	f.write(tok, lexeme)
+	f.prev = tok
+	f.prevEnd = len(f.out)
+	f.sep = after
+	f.indent += indent
+}
+
+// openLiteral pushes the frame for an array or hashmap literal.
+// literals are written one element per line if the source put the first
+// element on a new line
+func (f *formatter) openLiteral(tok, next *token.Token) (sep, int) {
+	closer := token.RSQR
+	if tok.Kind == token.LBRACE {
+		closer = token.RBRACE
+	}
+	first, _ := lines(next)
+	_, openLine := lines(tok)
+	fr := frame{open: tok, multiline: next.Kind != closer && first > openLine}
+	f.push(fr)
+	if fr.multiline {
+		return sep_NEWLINE, 1
+	}
+	return sep_NONE, 0
+}
@@ -0,0 +1,104 @@
+package format_test
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/format"
+	"github.com/Subarctic2796/gojlox/lexer"
+	"github.com/Subarctic2796/gojlox/parser"
+	"github.com/Subarctic2796/gojlox/printer"
+)
+
+// TestGolden formats each testdata/*.input and compares it against the
+// .golden file next to it, the golden files must already be formatted
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(input, ".input") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := format.Source(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if again, _ := format.Source(want); !bytes.Equal(again, want) {
+				t.Errorf("golden file isn't formatted, formatting it gives:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestInvalidSource(t *testing.T) {
+	_, err := format.Source([]byte("print (1;\nvar = 2;"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 2 {
+		t.Errorf("expected one line per error, got %q", err)
+	}
+}
+
+// parse returns the printed ast of src
+func parse(src []byte) string {
+	diags := diag.NewReporter(nil)
+	toks, _ := scanner.NewLexer(string(src), diags).ScanTokens()
+	stmts, _ := parser.NewParser(toks, diags).Parse()
+	return printer.Sprint(stmts)
+}
+
+// TestIdempotent formats every script in tests/ and examples/ twice, the
+// second time mustn't change anything, and neither time may change what
+// the script means
+func TestIdempotent(t *testing.T) {
+	for _, dir := range []string{"../tests", "../examples"} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".lox" {
+				return err
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			once, err := format.Source(src)
+			if err != nil {
+				// scripts that test errors can't be formatted
+				return nil
+			}
+			t.Run(path, func(t *testing.T) {
+				twice, err := format.Source(once)
+				if err != nil {
+					t.Fatalf("formatted script doesn't parse: %v\n%s", err, once)
+				}
+				if !bytes.Equal(once, twice) {
+					t.Errorf("formatting again changed it:\n%s\nto:\n%s", once, twice)
+				}
+				if before, after := parse(src), parse(once); before != after {
+					t.Errorf("formatting changed the ast from:\n%s\nto:\n%s", before, after)
+				}
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	unformatted = "var a=1;\nprint a;\n"
+	formatted   = "var a = 1;\nprint a;\n"
+)
+
+// writeScripts writes the named scripts to a new directory and returns it
+func writeScripts(t *testing.T, scripts map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readScript(t *testing.T, path string) string {
+	t.Helper()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+func TestFmtStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runFmt(nil, strings.NewReader(unformatted), &stdout, &stderr)
+	if code != 0 || stdout.String() != formatted {
+		t.Errorf("got %d %q, want 0 %q", code, stdout.String(), formatted)
+	}
+
+	stdout.Reset()
+	code = runFmt([]string{"-w"}, strings.NewReader(unformatted), &stdout, &stderr)
+	if code != 2 || !strings.Contains(stderr.String(), "can't use -w on standard input") {
+		t.Errorf("-w on stdin: got %d %q", code, stderr.String())
+	}
+}
+
+func TestFmtFiles(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"a.lox": unformatted, "b.lox": formatted, "notes.txt": unformatted})
+	var stdout, stderr bytes.Buffer
+	if code := runFmt([]string{dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	// only .lox files are formatted when walking a directory
+	if got, want := stdout.String(), formatted+formatted; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := readScript(t, filepath.Join(dir, "a.lox")); got != unformatted {
+		t.Errorf("a.lox was changed without -w: %q", got)
+	}
+}
+
+func TestFmtExplicitFiles(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"a.lox": unformatted, "b.txt": unformatted, "c.txt": unformatted})
+	var stdout, stderr bytes.Buffer
+	args := []string{"-w", filepath.Join(dir, "a.lox"), filepath.Join(dir, "b.txt")}
+	if code := runFmt(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	// files named on the command line are formatted even if they aren't
+	// .lox files, wherever they are in the arguments
+	for name, want := range map[string]string{"a.lox": formatted, "b.txt": formatted, "c.txt": unformatted} {
+		if got := readScript(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"a.lox": unformatted, "b.lox": formatted, "notes.txt": unformatted})
+	var stdout, stderr bytes.Buffer
+	if code := runFmt([]string{"-w", dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("-w printed %q", stdout.String())
+	}
+	for name, want := range map[string]string{"a.lox": formatted, "b.lox": formatted, "notes.txt": unformatted} {
+		if got := readScript(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFmtDiff(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"a.lox": unformatted, "b.lox": formatted})
+	a := filepath.Join(dir, "a.lox")
+	var stdout, stderr bytes.Buffer
+	if code := runFmt([]string{"-d", dir}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1 as a.lox isn't formatted", code)
+	}
+	want := "--- " + a + ".orig\n+++ " + a + "\n@@ -1,2 +1,2 @@\n-var a=1;\n+var a = 1;\n print a;\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := readScript(t, a); got != unformatted {
+		t.Errorf("-d changed a.lox: %q", got)
+	}
+
+	stdout.Reset()
+	if code := runFmt([]string{"-d", filepath.Join(dir, "b.lox")}, nil, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("formatted file: got %d %q, want 0 and no diff", code, stdout.String())
+	}
+}
+
+func TestFmtInvalid(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"bad.lox": "print (;\n", "good.lox": unformatted})
+	var stdout, stderr bytes.Buffer
+	if code := runFmt([]string{"-w", dir}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+	if bad := filepath.Join(dir, "bad.lox"); !strings.HasPrefix(stderr.String(), bad+":") {
+		t.Errorf("errors aren't prefixed with %s: %q", bad, stderr.String())
+	}
+	// the other files are still formatted
+	if got := readScript(t, filepath.Join(dir, "good.lox")); got != formatted {
+		t.Errorf("good.lox: got %q, want %q", got, formatted)
+	}
+}
@@ -3,6 +3,7 @@ package interpreter
 import (
 	"fmt"
 
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/token"
 )
 
@@ -20,14 +21,29 @@ func (e *Env) Define(name string, val any) {
 }
 
 func (e *Env) Get(name *token.Token) (any, error) {
-	if val, ok := e.Values[name.Lexeme]; ok {
-		return val, nil
+	for env := e; env != nil; env = env.Enclosing {
+		if val, ok := env.Values[name.Lexeme]; ok {
+			return val, nil
+		}
 	}
-	if e.Enclosing != nil {
-		return e.Enclosing.Get(name)
+	return nil, e.undefined(name)
+}
+
+// undefined makes the error for a variable that isn't in e or any of the
+// envs enclosing it, suggesting a similarly named variable if there is one
+func (e *Env) undefined(name *token.Token) error {
+	names := make([]string, 0)
+	for env := e; env != nil; env = env.Enclosing {
+		for k := range env.Values {
+			names = append(names, k)
+		}
+	}
+	return &RunTimeErr{
+		Tok:  name,
+		Msg:  fmt.Sprintf("Undefined variable '%s'", name.Lexeme),
+		Code: CodeUndefined,
+		Help: diag.Suggest(name.Lexeme, names),
 	}
-	msg := fmt.Sprintf("Undefined variable '%s'", name.Lexeme)
-	return nil, &RunTimeErr{Tok: name, Msg: msg}
 }
 
 func (e *Env) GetAt(dist int, name string) any {
@@ -44,17 +60,15 @@ func (e *Env) Ancestor(dist int) *Env {
 }
 
 func (e *Env) Assign(name *token.Token, val any) error {
-	if _, ok := e.Values[name.Lexeme]; ok {
-		e.Values[name.Lexeme] = val
-		return nil
-	}
 	// if 'a' in global and it gets updated in a local scope
 	// then we have to update it in the correct scope
-	if e.Enclosing != nil {
-		return e.Enclosing.Assign(name, val)
+	for env := e; env != nil; env = env.Enclosing {
+		if _, ok := env.Values[name.Lexeme]; ok {
+			env.Values[name.Lexeme] = val
+			return nil
+		}
 	}
-	msg := fmt.Sprintf("Undefined variable '%s'", name.Lexeme)
-	return &RunTimeErr{Tok: name, Msg: msg}
+	return e.undefined(name)
 }
 
 func (e *Env) AssignAt(dist int, name *token.Token, val any) {
@@ -0,0 +1,140 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// Global returns the value of the global variable called name,
+// including the ones defined from Go
+func (i *Interpreter) Global(name string) (any, bool) {
+	for env := i.Globals; env != nil; env = env.Enclosing {
+		if val, ok := env.Values[name]; ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Call calls the lox function or class callee from Go. args are converted
+// using ToLox, and the result is returned as a lox value, FromLox can be
+// used to convert it. errors in the script are returned as *RunTimeErr
+// and aren't reported.
+// calls made by Go functions while a script is running are part of that
+// run, otherwise the call is a run of its own, see CallContext
+func (i *Interpreter) Call(callee any, args ...any) (any, error) {
+	if len(i.frames) != 0 {
+		return i.callFromGo(callee, args)
+	}
+	return i.CallContext(context.Background(), callee, args...)
+}
+
+// CallContext calls callee like Call, until it returns or ctx is done, in
+// which case the returned *RunTimeErr wraps ctx.Err(). MaxSteps limits the
+// steps of this call on its own
+func (i *Interpreter) CallContext(ctx context.Context, callee any, args ...any) (any, error) {
+	prvCtx, prvSteps := i.ctx, i.steps
+	defer func() { i.ctx, i.steps = prvCtx, prvSteps }()
+	i.ctx, i.steps = ctx, 0
+	return i.callFromGo(callee, args)
+}
+
+func (i *Interpreter) callFromGo(callee any, args []any) (any, error) {
+	fn, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, &RunTimeErr{Msg: fmt.Sprintf("Can only call functions and classes, got %s", typeName(callee))}
+	}
+	callArgs := make([]any, 0, len(args)+1)
+	callArgs = append(callArgs, i)
+	for idx, arg := range args {
+		val, err := i.ToLox(arg)
+		if err != nil {
+			return nil, &RunTimeErr{Msg: fmt.Sprintf("argument %d: %s", idx+1, err), Code: CodeArgument}
+		}
+		callArgs = append(callArgs, val)
+	}
+	if fn.Arity() != -1 && len(args) != fn.Arity() {
+		msg := fmt.Sprintf("Expected %d arguments but got %d", fn.Arity(), len(args))
+		return nil, &RunTimeErr{Msg: msg, Code: CodeArity}
+	}
+	// there is no call site, so natives report errors without a position
+	prvTok := i.callTok
+	defer func() { i.callTok = prvTok }()
+	return i.call(fn, callArgs, nil)
+}
+
+// CallGlobal calls the global function or class called name, see Call
+func (i *Interpreter) CallGlobal(name string, args ...any) (any, error) {
+	callee, err := i.global(name)
+	if err != nil {
+		return nil, err
+	}
+	return i.Call(callee, args...)
+}
+
+// CallGlobalContext is CallGlobal with a context, see CallContext
+func (i *Interpreter) CallGlobalContext(ctx context.Context, name string, args ...any) (any, error) {
+	callee, err := i.global(name)
+	if err != nil {
+		return nil, err
+	}
+	return i.CallContext(ctx, callee, args...)
+}
+
+// global returns the global called name for CallGlobal
+func (i *Interpreter) global(name string) (any, error) {
+	callee, ok := i.Global(name)
+	if !ok {
+		names := make([]string, 0, len(i.Globals.Values))
+		for env := i.Globals; env != nil; env = env.Enclosing {
+			for global := range env.Values {
+				names = append(names, global)
+			}
+		}
+		return nil, &RunTimeErr{
+			Msg:  fmt.Sprintf("Undefined variable '%s'", name),
+			Code: CodeUndefined,
+			Help: diag.Suggest(name, names),
+		}
+	}
+	return callee, nil
+}
+
+// CallMethod calls the method called name on obj, see Call
+func (i *Interpreter) CallMethod(obj any, name string, args ...any) (any, error) {
+	method, err := i.method(obj, name)
+	if err != nil {
+		return nil, err
+	}
+	return i.Call(method, args...)
+}
+
+// CallMethodContext is CallMethod with a context, see CallContext
+func (i *Interpreter) CallMethodContext(ctx context.Context, obj any, name string, args ...any) (any, error) {
+	method, err := i.method(obj, name)
+	if err != nil {
+		return nil, err
+	}
+	return i.CallContext(ctx, method, args...)
+}
+
+// method returns the method called name of obj for CallMethod
+func (i *Interpreter) method(obj any, name string) (any, error) {
+	getter, ok := obj.(LoxGetter)
+	if !ok {
+		return nil, &RunTimeErr{Msg: fmt.Sprintf("Only instances have properties, got %s", typeName(obj))}
+	}
+	tok := token.NewToken(token.IDENTIFIER, name, nil, 0)
+	method, err := getter.Get(&tok)
+	if err != nil {
+		// tok isn't in the script so it has no position
+		if rte, ok := err.(*RunTimeErr); ok && rte.Tok == &tok {
+			rte.Tok = nil
+		}
+		return nil, err
+	}
+	return method, nil
+}
@@ -0,0 +1,156 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// GoFunc is the Go side of a native function, see RegisterFunc.
+// it must return a lox value: nil, float64, string, bool, or one of the
+// Lox* types. errors that aren't a *RunTimeErr are reported at the call
+type GoFunc func(args *Args) (any, error)
+
+// GoFn is a native function that was registered from Go
+type GoFn struct {
+	Name  string
+	arity int
+	fn    GoFunc
+}
+
+// NewGoFn makes a native function called name, arity is how many arguments
+// it takes, or -1 if it takes any number of them
+func NewGoFn(name string, arity int, fn GoFunc) *GoFn {
+	return &GoFn{name, arity, fn}
+}
+
+func (fn *GoFn) Call(args ...any) (any, error) {
+	intprt := args[0].(*Interpreter)
+	callArgs := &Args{intprt, args[1:], intprt.callTok, fn.Name}
+	val, err := fn.fn(callArgs)
+	if err != nil {
+		var rte *RunTimeErr
+		if errors.As(err, &rte) {
+			return nil, err
+		}
+		return nil, callArgs.Errorf("%s", err)
+	}
+	return val, nil
+}
+
+func (fn *GoFn) Arity() int     { return fn.arity }
+func (fn *GoFn) String() string { return fmt.Sprintf("<native fn %s>", fn.Name) }
+
+// RegisterFunc defines a native function called name that every module can
+// use, see NewGoFn for what arity means
+func (i *Interpreter) RegisterFunc(name string, arity int, fn GoFunc) {
+	i.builtins.Define(name, NewGoFn(name, arity, fn))
+}
+
+// RegisterModule defines a module called name that every module can use.
+// members must be lox values, functions can be made with NewGoFn
+func (i *Interpreter) RegisterModule(name string, members map[string]any) *LoxModule {
+	mod := NewLoxModule(name, members)
+	i.builtins.Define(name, mod)
+	return mod
+}
+
+// Args are the arguments a GoFunc was called with. the typed getters return
+// a *RunTimeErr at the call if the argument is missing or the wrong type,
+// so they can be returned as is
+type Args struct {
+	Interpreter *Interpreter
+	Values      []any
+	// `)` of the call
+	tok  *token.Token
+	name string
+}
+
+func (a *Args) Len() int { return len(a.Values) }
+
+// Errorf makes a *RunTimeErr at the call, the message is prefixed with
+// the name of the function
+func (a *Args) Errorf(format string, args ...any) error {
+	return &RunTimeErr{
+		Tok:  a.tok,
+		Msg:  fmt.Sprintf("%s: %s", a.name, fmt.Sprintf(format, args...)),
+		Code: CodeArgument,
+	}
+}
+
+// Value returns the idx'th argument, which can be of any type
+func (a *Args) Value(idx int) (any, error) {
+	if idx < 0 || idx >= len(a.Values) {
+		return nil, a.Errorf("missing argument %d", idx+1)
+	}
+	return a.Values[idx], nil
+}
+
+func (a *Args) Number(idx int) (float64, error) { return argAs[float64](a, idx, "number") }
+func (a *Args) String(idx int) (string, error)  { return argAs[string](a, idx, "string") }
+func (a *Args) Bool(idx int) (bool, error)      { return argAs[bool](a, idx, "bool") }
+func (a *Args) Array(idx int) (*LoxArray, error) {
+	return argAs[*LoxArray](a, idx, "array")
+}
+func (a *Args) HashMap(idx int) (*LoxHashMap, error) {
+	return argAs[*LoxHashMap](a, idx, "hashmap")
+}
+func (a *Args) Callable(idx int) (LoxCallable, error) {
+	return argAs[LoxCallable](a, idx, "function")
+}
+
+// Int returns the idx'th argument, which must be a whole number
+func (a *Args) Int(idx int) (int, error) {
+	num, err := a.Number(idx)
+	if err != nil {
+		return 0, err
+	}
+	if num != float64(int(num)) {
+		return 0, a.Errorf("argument %d must be a whole number, got %v", idx+1, num)
+	}
+	return int(num), nil
+}
+
+func argAs[T any](a *Args, idx int, want string) (T, error) {
+	var zero T
+	val, err := a.Value(idx)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := val.(T)
+	if !ok {
+		return zero, a.Errorf("argument %d must be a %s, got %s", idx+1, want, typeName(val))
+	}
+	return typed, nil
+}
+
+// typeName returns the name of the lox type of val, for error messages
+func typeName(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *LoxArray:
+		return "array"
+	case *LoxHashMap:
+		return "hashmap"
+	case *LoxInstance:
+		return fmt.Sprintf("%s instance", v.Klass.Name)
+	case *LoxModule:
+		return "module"
+	case *GoObject:
+		return "go object"
+	case *UserClass:
+		return "class"
+	case LoxCallable:
+		return "function"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,122 @@
+package interpreter_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Subarctic2796/gojlox/interpreter"
+	"github.com/Subarctic2796/gojlox/lox"
+)
+
+func registerFuncs(l *lox.Lox) {
+	l.RegisterFunc("repeat", 2, func(args *interpreter.Args) (any, error) {
+		s, err := args.String(0)
+		if err != nil {
+			return nil, err
+		}
+		n, err := args.Int(1)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Repeat(s, n), nil
+	})
+	l.RegisterFunc("second", -1, func(args *interpreter.Args) (any, error) {
+		return args.Value(1)
+	})
+	l.RegisterFunc("negate", 1, func(args *interpreter.Args) (any, error) {
+		b, err := args.Bool(0)
+		return !b, err
+	})
+	l.RegisterFunc("first", 1, func(args *interpreter.Args) (any, error) {
+		arr, err := args.Array(0)
+		if err != nil {
+			return nil, err
+		}
+		return arr.Items[0], nil
+	})
+	l.RegisterFunc("size", 1, func(args *interpreter.Args) (any, error) {
+		hm, err := args.HashMap(0)
+		if err != nil {
+			return nil, err
+		}
+		return float64(len(hm.Pairs)), nil
+	})
+	l.RegisterFunc("twice", 2, func(args *interpreter.Args) (any, error) {
+		fn, err := args.Callable(0)
+		if err != nil {
+			return nil, err
+		}
+		x, err := args.Number(1)
+		if err != nil {
+			return nil, err
+		}
+		once, err := args.Interpreter.Call(fn, x)
+		if err != nil {
+			return nil, err
+		}
+		return args.Interpreter.Call(fn, once)
+	})
+	l.RegisterFunc("fail", 0, func(args *interpreter.Args) (any, error) {
+		return nil, errors.New("it broke")
+	})
+}
+
+func TestRegisterFunc(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"string and int", `print repeat("ab", 3);`, "ababab\n"},
+		{"variadic", `print second(1, 2, 3);`, "2\n"},
+		{"bool", `print negate(false);`, "true\n"},
+		{"array", `print first([7, 8]);`, "7\n"},
+		{"hashmap", `var m = {"a": 1, "b": 2}; print size(m);`, "2\n"},
+		{"callable", `print twice(fun(x) { return x * 3; }, 2);`, "18\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runLox(t, registerFuncs, tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	tests := []struct {
+		name, src string
+		code, msg string
+	}{
+		{"too few", `repeat("a");`, interpreter.CodeArity, "Expected 2 arguments but got 1"},
+		{"too many", `negate(true, false);`, interpreter.CodeArity, "Expected 1 arguments but got 2"},
+		{"missing", `second(1);`, interpreter.CodeArgument, "second: missing argument 2"},
+		{"number", `twice(fun(x) { return x; }, "1");`, interpreter.CodeArgument, "twice: argument 2 must be a number, got string"},
+		{"string", `repeat(1, 2);`, interpreter.CodeArgument, "repeat: argument 1 must be a string, got number"},
+		{"whole number", `repeat("a", 1.5);`, interpreter.CodeArgument, "repeat: argument 2 must be a whole number, got 1.5"},
+		{"bool", `negate(nil);`, interpreter.CodeArgument, "negate: argument 1 must be a bool, got nil"},
+		{"array", `first("ab");`, interpreter.CodeArgument, "first: argument 1 must be a array, got string"},
+		{"hashmap", `size([]);`, interpreter.CodeArgument, "size: argument 1 must be a hashmap, got array"},
+		{"callable", `twice(1, 2);`, interpreter.CodeArgument, "twice: argument 1 must be a function, got number"},
+		{"plain error", `fail();`, interpreter.CodeArgument, "fail: it broke"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runLox(t, registerFuncs, "\n"+tt.src)
+			var rte *interpreter.RunTimeErr
+			if !errors.As(err, &rte) {
+				t.Fatalf("got %v, want a *RunTimeErr", err)
+			}
+			if rte.Code != tt.code || rte.Msg != tt.msg {
+				t.Errorf("got [%s] %q, want [%s] %q", rte.Code, rte.Msg, tt.code, tt.msg)
+			}
+			// errors are reported at the call
+			if rte.Tok == nil || rte.Tok.Line != 2 {
+				t.Errorf("error is at %v, want line 2", rte.Tok)
+			}
+		})
+	}
+}
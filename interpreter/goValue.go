@@ -0,0 +1,475 @@
+package interpreter
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// conversions between Go values and lox values:
+//
+//	Go                               lox
+//	bool, string                     bool, string
+//	ints, uints, floats              number
+//	slices, arrays                   *LoxArray
+//	maps                             *LoxHashMap
+//	funcs                            *GoFn
+//	struct pointers, structs         *GoObject
+//	nil pointers, slices, maps, ...  nil
+//
+// values that are already lox values are left as they are
+
+var (
+	errorType = reflect.TypeFor[error]()
+	anyType   = reflect.TypeFor[any]()
+)
+
+// Bind converts v to a lox value using ToLox and defines it as a global
+// that every module can use
+func (i *Interpreter) Bind(name string, v any) error {
+	if fn := reflect.ValueOf(v); fn.Kind() == reflect.Func && !fn.IsNil() {
+		// so that errors say which function they came from
+		i.builtins.Define(name, i.goFunc(name, fn))
+		return nil
+	}
+	val, err := i.ToLox(v)
+	if err != nil {
+		return err
+	}
+	i.builtins.Define(name, val)
+	return nil
+}
+
+// ToLox converts the Go value v to a lox value
+func (i *Interpreter) ToLox(v any) (any, error) {
+	switch v.(type) {
+	case nil, float64, string, bool, *LoxArray, *LoxHashMap, *LoxInstance,
+		*LoxModule, *GoObject, LoxCallable:
+		return v, nil
+	}
+	return i.toLox(reflect.ValueOf(v))
+}
+
+func (i *Interpreter) toLox(v reflect.Value) (any, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return i.ToLox(v.Elem().Interface())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return &GoObject{v, i}, nil
+		}
+		return i.toLox(v.Elem())
+	case reflect.Struct:
+		// copy it so that its fields can be set
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return &GoObject{ptr, i}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if err := i.allocElems(i.callTok, v.Len()); err != nil {
+			return nil, err
+		}
+		items := make([]any, 0, v.Len())
+		for idx := range v.Len() {
+			item, err := i.toLox(v.Index(idx))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return &LoxArray{items}, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if err := i.allocEntries(i.callTok, v.Len()); err != nil {
+			return nil, err
+		}
+		pairs := make(map[any]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := i.toLox(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			if err = Hashable(key); err != nil {
+				return nil, err
+			}
+			val, err := i.toLox(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			pairs[key] = val
+		}
+		return &LoxHashMap{pairs}, nil
+	case reflect.Func:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return i.goFunc("", v), nil
+	}
+	if v.IsValid() && v.CanInterface() {
+		if val, ok := v.Interface().(LoxCallable); ok {
+			return val, nil
+		}
+	}
+	return nil, fmt.Errorf("can't convert Go type '%s' to a lox value", v.Type())
+}
+
+// FromLox converts the lox value val to a Go value and stores it in the
+// value that ptr points to, ptr must be a non nil pointer
+func (i *Interpreter) FromLox(val any, ptr any) error {
+	target := reflect.ValueOf(ptr)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("can't convert to a non pointer '%T'", ptr)
+	}
+	v, err := i.fromLox(val, target.Type().Elem())
+	if err != nil {
+		return err
+	}
+	target.Elem().Set(v)
+	return nil
+}
+
+func (i *Interpreter) fromLox(val any, typ reflect.Type) (reflect.Value, error) {
+	if val == nil {
+		return reflect.Zero(typ), nil
+	}
+	if obj, ok := val.(*GoObject); ok {
+		if obj.val.Type().AssignableTo(typ) {
+			return obj.val, nil
+		}
+		if obj.val.Elem().Type().AssignableTo(typ) {
+			return obj.val.Elem(), nil
+		}
+	}
+	if typ.Kind() == reflect.Interface {
+		if typ == anyType {
+			return reflect.ValueOf(i.goValue(val)), nil
+		}
+		if v := reflect.ValueOf(val); v.Type().Implements(typ) {
+			return v, nil
+		}
+		return reflect.Value{}, convErr(val, typ)
+	}
+
+	v := reflect.New(typ).Elem()
+	switch val := val.(type) {
+	case bool:
+		if typ.Kind() == reflect.Bool {
+			v.SetBool(val)
+			return v, nil
+		}
+	case string:
+		if typ.Kind() == reflect.String {
+			v.SetString(val)
+			return v, nil
+		}
+	case float64:
+		switch typ.Kind() {
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(val)
+			return v, nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if val != float64(int64(val)) || v.OverflowInt(int64(val)) {
+				return reflect.Value{}, fmt.Errorf("%v doesn't fit in a '%s'", val, typ)
+			}
+			v.SetInt(int64(val))
+			return v, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if val < 0 || val != float64(uint64(val)) || v.OverflowUint(uint64(val)) {
+				return reflect.Value{}, fmt.Errorf("%v doesn't fit in a '%s'", val, typ)
+			}
+			v.SetUint(uint64(val))
+			return v, nil
+		}
+	case *LoxArray:
+		switch typ.Kind() {
+		case reflect.Slice:
+			v = reflect.MakeSlice(typ, len(val.Items), len(val.Items))
+		case reflect.Array:
+			if len(val.Items) != typ.Len() {
+				return reflect.Value{}, fmt.Errorf("can't convert an array of length %d to a '%s'", len(val.Items), typ)
+			}
+		default:
+			return reflect.Value{}, convErr(val, typ)
+		}
+		for idx, item := range val.Items {
+			elm, err := i.fromLox(item, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(idx).Set(elm)
+		}
+		return v, nil
+	case *LoxHashMap:
+		if typ.Kind() != reflect.Map {
+			break
+		}
+		v = reflect.MakeMapWithSize(typ, len(val.Pairs))
+		for key, value := range val.Pairs {
+			k, err := i.fromLox(key, typ.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elm, err := i.fromLox(value, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.SetMapIndex(k, elm)
+		}
+		return v, nil
+	case LoxCallable:
+		if typ.Kind() == reflect.Func {
+			return i.loxFunc(val, typ), nil
+		}
+	}
+	return reflect.Value{}, convErr(val, typ)
+}
+
+// goValue converts val to the Go value used for it in an `any`
+func (i *Interpreter) goValue(val any) any {
+	switch val := val.(type) {
+	case *GoObject:
+		return val.val.Interface()
+	case *LoxArray:
+		items := make([]any, 0, len(val.Items))
+		for _, item := range val.Items {
+			items = append(items, i.goValue(item))
+		}
+		return items
+	case *LoxHashMap:
+		pairs := make(map[any]any, len(val.Pairs))
+		for key, value := range val.Pairs {
+			pairs[key] = i.goValue(value)
+		}
+		return pairs
+	default:
+		return val
+	}
+}
+
+func convErr(val any, typ reflect.Type) error {
+	return fmt.Errorf("can't convert %s to a '%s'", typeName(val), typ)
+}
+
+// goFunc wraps the Go function fn so it can be called from lox.
+// if the last result of fn is an error, it is reported as a runtime error
+func (i *Interpreter) goFunc(name string, fn reflect.Value) *GoFn {
+	typ := fn.Type()
+	arity := typ.NumIn()
+	if typ.IsVariadic() {
+		arity = -1
+	}
+	if name == "" {
+		name = "go func"
+	}
+	return NewGoFn(name, arity, func(args *Args) (any, error) {
+		if typ.IsVariadic() && args.Len() < typ.NumIn()-1 {
+			return nil, args.Errorf("expected at least %d arguments but got %d", typ.NumIn()-1, args.Len())
+		}
+		in := make([]reflect.Value, 0, args.Len())
+		for idx, arg := range args.Values {
+			argType := typ.In(min(idx, typ.NumIn()-1))
+			if typ.IsVariadic() && idx >= typ.NumIn()-1 {
+				argType = argType.Elem()
+			}
+			v, err := i.fromLox(arg, argType)
+			if err != nil {
+				return nil, args.Errorf("argument %d: %s", idx+1, err)
+			}
+			in = append(in, v)
+		}
+		prvErr := i.callbackErr
+		i.callbackErr = nil
+		out := fn.Call(in)
+		err := i.callbackErr
+		i.callbackErr = prvErr
+		if err != nil {
+			// a lox function it called failed
+			return nil, err
+		}
+		if len(out) != 0 && typ.Out(len(out)-1) == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, err
+			}
+			out = out[:len(out)-1]
+		}
+		switch len(out) {
+		case 0:
+			return nil, nil
+		case 1:
+			return i.toLox(out[0])
+		default:
+			// return every result as an array
+			if err := i.allocElems(i.callTok, len(out)); err != nil {
+				return nil, err
+			}
+			items := make([]any, 0, len(out))
+			for _, res := range out {
+				item, err := i.toLox(res)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return &LoxArray{items}, nil
+		}
+	})
+}
+
+// loxFunc wraps the lox function fn so it can be called from Go as a func
+// of type typ. if typ returns an error as its last result, runtime errors
+// are returned through it. otherwise the func returns zero values, and the
+// error is raised in the script when the Go function that called the func
+// returns. until then the func doesn't call fn again
+func (i *Interpreter) loxFunc(fn LoxCallable, typ reflect.Type) reflect.Value {
+	return reflect.MakeFunc(typ, func(in []reflect.Value) []reflect.Value {
+		if i.callbackErr != nil && !returnsErr(typ) {
+			return i.funcResults(typ, nil, nil)
+		}
+		args := make([]any, 0, len(in))
+		for _, arg := range in {
+			args = append(args, arg.Interface())
+		}
+		res, err := i.Call(fn, args...)
+		if err != nil {
+			return i.funcResults(typ, nil, err)
+		}
+		if typ.NumOut() == 0 || typ.Out(0) == errorType {
+			return i.funcResults(typ, nil, nil)
+		}
+		v, err := i.fromLox(res, typ.Out(0))
+		return i.funcResults(typ, []reflect.Value{v}, err)
+	})
+}
+
+func returnsErr(typ reflect.Type) bool {
+	return typ.NumOut() != 0 && typ.Out(typ.NumOut()-1) == errorType
+}
+
+// funcResults makes the results of a func of type typ, filling in any
+// results that aren't in vals with zero values
+func (i *Interpreter) funcResults(typ reflect.Type, vals []reflect.Value, err error) []reflect.Value {
+	hasErr := returnsErr(typ)
+	if err != nil && !hasErr {
+		// the func can't return it, so keep it for goFunc to raise
+		i.callbackErr = err
+	}
+	out := make([]reflect.Value, typ.NumOut())
+	for idx := range out {
+		switch {
+		case hasErr && idx == len(out)-1:
+			out[idx] = reflect.Zero(errorType)
+			if err != nil {
+				out[idx] = reflect.ValueOf(&err).Elem()
+			}
+		case err == nil && idx < len(vals):
+			out[idx] = vals[idx]
+		default:
+			out[idx] = reflect.Zero(typ.Out(idx))
+		}
+	}
+	return out
+}
+
+// GoObject is a pointer to a Go struct that scripts can use like an
+// instance, its exported fields and methods are its properties
+type GoObject struct {
+	val    reflect.Value
+	intprt *Interpreter
+}
+
+// Value returns the pointer to the struct
+func (obj *GoObject) Value() any { return obj.val.Interface() }
+
+func (obj *GoObject) Get(name *token.Token) (any, error) {
+	if method := obj.val.MethodByName(name.Lexeme); method.IsValid() {
+		return obj.intprt.goFunc(name.Lexeme, method), nil
+	}
+	if field, ok := obj.field(name.Lexeme); ok {
+		if field.Kind() == reflect.Struct {
+			// so that setting its fields changes obj
+			return &GoObject{field.Addr(), obj.intprt}, nil
+		}
+		val, err := obj.intprt.toLox(field)
+		if err != nil {
+			return nil, &RunTimeErr{Tok: name, Msg: err.Error()}
+		}
+		return val, nil
+	}
+	return nil, obj.undefined(name)
+}
+
+func (obj *GoObject) Set(name *token.Token, val any) error {
+	field, ok := obj.field(name.Lexeme)
+	if !ok {
+		return obj.undefined(name)
+	}
+	v, err := obj.intprt.fromLox(val, field.Type())
+	if err != nil {
+		return &RunTimeErr{Tok: name, Msg: fmt.Sprintf("Can't set '%s': %s", name.Lexeme, err)}
+	}
+	field.Set(v)
+	return nil
+}
+
+// field returns the exported field called name
+func (obj *GoObject) field(name string) (reflect.Value, bool) {
+	sf, ok := obj.val.Elem().Type().FieldByName(name)
+	if !ok || !sf.IsExported() {
+		return reflect.Value{}, false
+	}
+	field, err := obj.val.Elem().FieldByIndexErr(sf.Index)
+	if err != nil {
+		// embedded through a nil pointer
+		return reflect.Value{}, false
+	}
+	return field, true
+}
+
+func (obj *GoObject) undefined(name *token.Token) error {
+	typ := obj.val.Type()
+	names := make([]string, 0, typ.NumMethod()+typ.Elem().NumField())
+	for idx := range typ.NumMethod() {
+		names = append(names, typ.Method(idx).Name)
+	}
+	for _, field := range reflect.VisibleFields(typ.Elem()) {
+		if field.IsExported() {
+			names = append(names, field.Name)
+		}
+	}
+	return &RunTimeErr{
+		Tok:  name,
+		Msg:  fmt.Sprintf("Undefined property '%s'", name.Lexeme),
+		Code: CodeProperty,
+		Help: diag.Suggest(name.Lexeme, names),
+	}
+}
+
+func (obj *GoObject) String() string {
+	if str, ok := obj.val.Interface().(fmt.Stringer); ok {
+		return str.String()
+	}
+	return fmt.Sprintf("<go %s>", obj.val.Type())
+}
@@ -0,0 +1,232 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/interpreter"
+	"github.com/Subarctic2796/gojlox/lox"
+)
+
+type point struct {
+	X, Y int
+	Name string
+}
+
+func (p *point) Sum() int { return p.X + p.Y }
+
+func TestRoundTrip(t *testing.T) {
+	intprt := interpreter.NewInterpreter(diag.NewReporter(nil))
+	tests := []struct {
+		name string
+		val  any
+	}{
+		{"bool", true},
+		{"string", "hi"},
+		{"int", 42},
+		{"int8", int8(-8)},
+		{"uint16", uint16(65535)},
+		{"float32", float32(1.5)},
+		{"float64", 2.25},
+		{"slice", []int{1, 2, 3}},
+		{"nil slice", []int(nil)},
+		{"nested slice", [][]string{{"a"}, {"b", "c"}}},
+		{"array", [3]string{"a", "b", "c"}},
+		{"string map", map[string]int{"a": 1, "b": 2}},
+		{"number map", map[int]bool{1: true, 2: false}},
+		{"any slice", []any{1.0, "a", true, nil}},
+		{"any map", map[string]any{"a": 1.0, "b": []any{"c"}}},
+		{"struct", point{1, 2, "p"}},
+		{"struct slice", []point{{1, 2, "a"}, {3, 4, "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := intprt.ToLox(tt.val)
+			if err != nil {
+				t.Fatalf("ToLox: %v", err)
+			}
+			out := reflect.New(reflect.TypeOf(tt.val))
+			if err := intprt.FromLox(val, out.Interface()); err != nil {
+				t.Fatalf("FromLox: %v", err)
+			}
+			if got := out.Elem().Interface(); !reflect.DeepEqual(got, tt.val) {
+				t.Errorf("got %#v, want %#v", got, tt.val)
+			}
+		})
+	}
+
+	t.Run("struct pointer", func(t *testing.T) {
+		p := &point{X: 1}
+		val, err := intprt.ToLox(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got *point
+		if err := intprt.FromLox(val, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != p {
+			t.Errorf("got %p, want the same pointer %p", got, p)
+		}
+	})
+}
+
+func TestConversionErrors(t *testing.T) {
+	intprt := interpreter.NewInterpreter(diag.NewReporter(nil))
+	arr, _ := intprt.ToLox([]int{1, 2})
+	tests := []struct {
+		name string
+		val  any
+		ptr  any
+		want string
+	}{
+		{"fraction to int", 1.5, new(int), "1.5 doesn't fit in a 'int'"},
+		{"negative to uint", -1.0, new(uint), "-1 doesn't fit in a 'uint'"},
+		{"overflow", 300.0, new(int8), "300 doesn't fit in a 'int8'"},
+		{"string to int", "a", new(int), "can't convert string to a 'int'"},
+		{"array length", arr, new([3]int), "can't convert an array of length 2 to a '[3]int'"},
+		{"array to map", arr, new(map[string]int), "can't convert array to a 'map[string]int'"},
+		{"not a pointer", 1.0, 1, "can't convert to a non pointer 'int'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := intprt.FromLox(tt.val, tt.ptr)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("unsupported type", func(t *testing.T) {
+		if _, err := intprt.ToLox(make(chan int)); err == nil {
+			t.Error("expected an error converting a chan")
+		}
+	})
+	t.Run("unhashable key", func(t *testing.T) {
+		if _, err := intprt.ToLox(map[[2]int]int{{1, 2}: 3}); err == nil {
+			t.Error("expected an error converting a map with array keys")
+		}
+	})
+}
+
+func runLox(t *testing.T, setup func(l *lox.Lox), src string) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	l := lox.NewLox(lox.WithStdout(&stdout), lox.WithStderr(&bytes.Buffer{}))
+	setup(l)
+	err := l.Run(src)
+	return stdout.String(), err
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"func", `print add(1, 2);`, "3\n"},
+		{"variadic", `print sum(1, 2, 3);`, "6\n"},
+		{"error result", `print div(6, 3);`, "2\n"},
+		{"many results", `print pair()[1];`, "b\n"},
+		{"struct", `print pt.X + pt.Sum(); pt.Y = 5; print pt.Y;`, "3\n5\n"},
+		{"callback", `print apply(fun(x) { return x * 2; }, 3);`, "6\n"},
+		{"callback error result", `print tryApply(fun(x) { return x + 1; }, 3);`, "4\n"},
+		{"map", `print names["a"];`, "1\n"},
+	}
+	setup := func(l *lox.Lox) {
+		binds := map[string]any{
+			"add": func(a, b int) int { return a + b },
+			"sum": func(nums ...float64) float64 {
+				total := 0.0
+				for _, n := range nums {
+					total += n
+				}
+				return total
+			},
+			"div": func(a, b int) (int, error) {
+				if b == 0 {
+					return 0, errors.New("divide by zero")
+				}
+				return a / b, nil
+			},
+			"pair":     func() (string, string) { return "a", "b" },
+			"pt":       &point{X: 1, Y: 1},
+			"apply":    func(f func(int) int, x int) int { return f(x) },
+			"tryApply": func(f func(int) (int, error), x int) (int, error) { return f(x) },
+			"names":    map[string]int{"a": 1},
+		}
+		for name, v := range binds {
+			if err := l.Bind(name, v); err != nil {
+				t.Fatalf("Bind(%q): %v", name, err)
+			}
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runLox(t, setup, tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindCallbackErrors(t *testing.T) {
+	calls := 0
+	setup := func(l *lox.Lox) {
+		_ = l.Bind("each", func(xs []int, f func(int)) {
+			for _, x := range xs {
+				f(x)
+			}
+		})
+		_ = l.Bind("tryApply", func(f func(int) (int, error), x int) (int, error) {
+			calls++
+			return f(x)
+		})
+	}
+
+	t.Run("no error result", func(t *testing.T) {
+		// the script fails instead of the host panicking, and the callback
+		// isn't called again after it failed
+		got, err := runLox(t, setup, `each([1, 2, 3], fun(x) { print x; if (x == 2) nil.y; });`)
+		var rte *interpreter.RunTimeErr
+		if !errors.As(err, &rte) || rte.Msg != "Only instances have properties" {
+			t.Fatalf("got %v, want the callback's error", err)
+		}
+		if got != "1\n2\n" {
+			t.Errorf("got %q, want %q", got, "1\n2\n")
+		}
+	})
+
+	t.Run("catchable", func(t *testing.T) {
+		src := `
+try {
+  each([1], fun(x) { throw TypeError("bad " + string(x)); });
+} catch (e) {
+  print e.message;
+}
+each([1], fun(x) { print x; });`
+		got, err := runLox(t, setup, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "bad 1\n1\n" {
+			t.Errorf("got %q, want %q", got, "bad 1\n1\n")
+		}
+	})
+
+	t.Run("error result", func(t *testing.T) {
+		_, err := runLox(t, setup, `tryApply(fun(x) { return x.y; }, 1);`)
+		if err == nil || !strings.Contains(err.Error(), "Only instances have properties") {
+			t.Fatalf("got %v, want the callback's error", err)
+		}
+		if calls != 1 {
+			t.Errorf("tryApply was called %d times, want 1", calls)
+		}
+	})
+}
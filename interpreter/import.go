@@ -0,0 +1,115 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/diag"
+)
+
+// Importer finds and loads the modules named by import statements
+type Importer interface {
+	// Resolve returns the key of the module at path, imported by the file
+	// called from. every path to the same module must give the same key
+	Resolve(path, from string) (string, error)
+	// Load returns the statements of the module with the given key,
+	// already resolved by the resolver of this interpreter
+	Load(key string) ([]ast.Stmt, error)
+}
+
+// pendingImport is a module that is being imported
+type pendingImport struct {
+	key string
+	// the path as it was written in the import statement
+	path string
+}
+
+// InterpretFileContext runs stmts like InterpretContext, as the script
+// file with the given key, see Importer.Resolve. the file counts as being
+// imported while it runs, so an import cycle back to it is reported rather
+// than running it a second time, and once it has run later imports of it
+// get its globals
+func (i *Interpreter) InterpretFileContext(ctx context.Context, key string, stmts []ast.Stmt) error {
+	i.importing = append(i.importing[:0], pendingImport{key, path.Base(filepath.ToSlash(key))})
+	err := i.InterpretContext(ctx, stmts)
+	i.importing = i.importing[:0]
+	if err != nil {
+		return err
+	}
+	i.modules[key] = NewLoxModule(key, i.Globals.Values)
+	return nil
+}
+
+// importModule runs the module stmt imports the first time it is imported,
+// later imports of it get the same module
+func (i *Interpreter) importModule(stmt *ast.Import) (*LoxModule, error) {
+	path := stmt.Path.Literal.(string)
+	if i.Importer == nil {
+		return nil, &RunTimeErr{Tok: stmt.Path, Msg: "Imports aren't supported", Code: CodeImport}
+	}
+	from := ""
+	if stmt.Path.File != nil {
+		from = stmt.Path.File.Name
+	}
+	key, err := i.Importer.Resolve(path, from)
+	if err != nil {
+		return nil, i.importErr(stmt, err)
+	}
+	if mod, ok := i.modules[key]; ok {
+		return mod, nil
+	}
+	if idx := slices.IndexFunc(i.importing, func(pi pendingImport) bool { return pi.key == key }); idx != -1 {
+		cycle := make([]string, 0, len(i.importing)-idx+1)
+		for _, pi := range i.importing[idx:] {
+			cycle = append(cycle, pi.path)
+		}
+		cycle = append(cycle, path)
+		return nil, &RunTimeErr{
+			Tok:  stmt.Path,
+			Msg:  fmt.Sprintf("Import cycle: %s", strings.Join(cycle, " -> ")),
+			Code: CodeImport,
+		}
+	}
+	stmts, err := i.Importer.Load(key)
+	if err != nil {
+		return nil, i.importErr(stmt, err)
+	}
+
+	env := NewEnv(i.builtins)
+	prvGlobals := i.Globals
+	i.Globals = env
+	i.importing = append(i.importing, pendingImport{key, path})
+	i.frames = append(i.frames, diag.Frame{Name: "<module>", Tok: stmt.Path})
+	_, err = i.executeBlock(stmts, env)
+	err = i.popFrame(err, stmt.Path)
+	i.importing = i.importing[:len(i.importing)-1]
+	i.Globals = prvGlobals
+	if err != nil {
+		return nil, err
+	}
+	mod := NewLoxModule(path, env.Values)
+	i.modules[key] = mod
+	return mod, nil
+}
+
+func (i *Interpreter) importErr(stmt *ast.Import, err error) *RunTimeErr {
+	return &RunTimeErr{
+		Tok:  stmt.Path,
+		Msg:  fmt.Sprintf("Can't import '%s': %s", stmt.Path.Literal, err),
+		Code: CodeImport,
+		Err:  err,
+	}
+}
+
+// moduleEnv returns the globals of the module that env is in
+func (i *Interpreter) moduleEnv(env *Env) *Env {
+	for env.Enclosing != nil && env.Enclosing != i.builtins {
+		env = env.Enclosing
+	}
+	return env
+}
@@ -1,30 +1,76 @@
 package interpreter
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"unicode/utf8"
 
 	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/token"
 )
 
 type Interpreter struct {
+	// the globals of the module that is running, they enclose builtins
 	Globals, env *Env
-	locals       map[ast.Expr]int
-	CurErr       error
-	tmpBin       *ast.Binary
+	// the natives, error classes and everything defined from Go,
+	// shared by every module
+	builtins *Env
+	// where `print` and `printf` write to
+	Stdout io.Writer
+	locals map[ast.Expr]int
+	CurErr error
+	tmpBin *ast.Binary
+	diags  *diag.Reporter
+	// `)` of the call being made, natives use it to report errors
+	callTok *token.Token
+	// the context of the running script, it is checked in loops and calls
+	ctx context.Context
+	// the maximum number of steps, loop iterations and calls, a script can
+	// run for, 0 means there is no limit
+	MaxSteps int
+	steps    int
+	// the maximum number of calls that can be in progress at once,
+	// 0 means there is no limit
+	MaxDepth int
+	// the calls in progress, outermost first
+	frames []diag.Frame
+	Limits Limits
+	// what the current run has allocated so far, checked against Limits
+	allocs Limits
+	// the built in error classes, by name
+	errClasses map[string]*UserClass
+	// loads the modules named by import statements, nil if imports
+	// aren't allowed
+	Importer Importer
+	// modules that have been imported, by key
+	modules map[string]*LoxModule
+	// the modules being imported, used to find import cycles
+	importing []pendingImport
+	// the error of a lox function called through a Go func that has no
+	// error result, it is raised when the Go function that made the call
+	// returns to the script
+	callbackErr error
 }
 
-func NewInterpreter() *Interpreter {
-	globals := NewEnv(nil)
+// DefaultMaxDepth is the MaxDepth of new interpreters, it is well below
+// the depth that would overflow the Go stack
+const DefaultMaxDepth = 4096
+
+func NewInterpreter(diags *diag.Reporter) *Interpreter {
+	builtins := NewEnv(nil)
 	for name, fn := range NativeFns {
-		globals.Define(name, fn)
+		builtins.Define(name, fn)
 	}
+	globals := NewEnv(builtins)
 	tok := token.NewToken(token.NONE, "", nil, -1)
-	return &Interpreter{
+	intprt := &Interpreter{
 		globals,
 		globals,
+		builtins,
+		os.Stdout,
 		make(map[ast.Expr]int),
 		nil,
 		&ast.Binary{
@@ -32,10 +78,36 @@ func NewInterpreter() *Interpreter {
 			Operator: &tok,
 			Right:    nil,
 		},
+		diags,
+		nil,
+		context.Background(),
+		0,
+		0,
+		DefaultMaxDepth,
+		make([]diag.Frame, 0),
+		Limits{},
+		Limits{},
+		make(map[string]*UserClass),
+		nil,
+		make(map[string]*LoxModule),
+		make([]pendingImport, 0),
+		nil,
 	}
+	intprt.defineErrorClasses()
+	return intprt
 }
 
 func (i *Interpreter) Interpret(stmts []ast.Stmt) error {
+	return i.InterpretContext(context.Background(), stmts)
+}
+
+// InterpretContext runs stmts until they finish or ctx is done, in which
+// case the returned *RunTimeErr wraps ctx.Err()
+func (i *Interpreter) InterpretContext(ctx context.Context, stmts []ast.Stmt) error {
+	prvCtx := i.ctx
+	defer func() { i.ctx = prvCtx }()
+	i.ctx, i.steps, i.frames, i.callbackErr = ctx, 0, i.frames[:0], nil
+	i.allocs = Limits{}
 	for _, s := range stmts {
 		_, err := i.execute(s)
 		if err != nil {
@@ -86,18 +158,16 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 		fn, ok := callee.(LoxCallable)
 		if !ok {
 			return nil, &RunTimeErr{
-				Tok: e.Paren,
-				Msg: "Can only call functions and classes",
+				Tok:   e.Paren,
+				Msg:   "Can only call functions and classes",
+				Class: ClsTypeError,
 			}
 		}
-		if fn.Arity() == -1 {
-			return fn.Call(args...)
-		}
-		if len(args)-1 != fn.Arity() {
+		if fn.Arity() != -1 && len(args)-1 != fn.Arity() {
 			msg := fmt.Sprintf("Expected %d arguments but got %d", fn.Arity(), len(args)-1)
-			return nil, &RunTimeErr{Tok: e.Paren, Msg: msg}
+			return nil, &RunTimeErr{Tok: e.Paren, Msg: msg, Code: CodeArity}
 		}
-		return fn.Call(args...)
+		return i.call(fn, args, e.Paren)
 	case *ast.Get:
 		obj, err := i.evaluate(e.Object)
 		if err != nil {
@@ -115,21 +185,22 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 				return static, nil
 			}
 		}
-		if inst, ok := obj.(*LoxInstance); ok {
-			return inst.Get(e.Name)
+		if getter, ok := obj.(LoxGetter); ok {
+			return getter.Get(e.Name)
 		}
 		return nil, &RunTimeErr{
-			Tok: e.Name,
-			Msg: "Only instances have properties",
+			Tok:   e.Name,
+			Msg:   "Only instances have properties",
+			Class: ClsTypeError,
 		}
 	case *ast.HashLiteral:
 		pairs := make(map[any]any)
-		for key, val := range e.Pairs {
-			k, err := i.evaluate(key)
+		for _, pair := range e.Pairs {
+			k, err := i.evaluate(pair.Key)
 			if err != nil {
 				return nil, err
 			}
-			v, err := i.evaluate(val)
+			v, err := i.evaluate(pair.Value)
 			if err != nil {
 				return nil, err
 			}
@@ -139,6 +210,9 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 			}
 			pairs[k] = v
 		}
+		if err := i.allocEntries(e.Brace, len(pairs)); err != nil {
+			return nil, err
+		}
 		return &LoxHashMap{pairs}, nil
 	case *ast.IndexedSet:
 		tmpErr := &RunTimeErr{Tok: e.Sqr, Msg: ""}
@@ -148,7 +222,7 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 		}
 		iter, ok := obj.(LoxIterable)
 		if !ok {
-			tmpErr.Msg = "Only iterables can be set using an index"
+			tmpErr.Msg, tmpErr.Class = "Only iterables can be set using an index", ClsTypeError
 			return nil, tmpErr
 		}
 		val, err := i.evaluate(e.Value)
@@ -159,9 +233,16 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		if hm, ok := iter.(*LoxHashMap); ok && Hashable(idx) == nil {
+			if _, ok := hm.Pairs[idx]; !ok {
+				if err = i.allocEntries(e.Sqr, 1); err != nil {
+					return nil, err
+				}
+			}
+		}
 		err = iter.IndexSet(idx, val)
 		if err != nil {
-			return nil, &RunTimeErr{Tok: e.Sqr, Msg: fmt.Sprint(err)}
+			return nil, at(err, e.Sqr, CodeIndex)
 		}
 		return val, nil
 	case *ast.Set:
@@ -169,18 +250,21 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 		if err != nil {
 			return nil, err
 		}
-		inst, ok := obj.(*LoxInstance)
+		setter, ok := obj.(LoxSetter)
 		if !ok {
 			return nil, &RunTimeErr{
-				Tok: e.Name,
-				Msg: "Only instances have fields",
+				Tok:   e.Name,
+				Msg:   "Only instances have fields",
+				Class: ClsTypeError,
 			}
 		}
 		val, err := i.evaluate(e.Value)
 		if err != nil {
 			return nil, err
 		}
-		inst.Set(e.Name, val)
+		if err = setter.Set(e.Name, val); err != nil {
+			return nil, err
+		}
 		return val, nil
 	case *ast.Super:
 		dist := i.locals[e]
@@ -189,8 +273,9 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 		method := superclass.FindMethod(e.Method.Lexeme)
 		if method == nil {
 			return nil, &RunTimeErr{
-				Tok: e.Method,
-				Msg: fmt.Sprintf("Undefined property '%s'", e.Method.Lexeme),
+				Tok:  e.Method,
+				Msg:  fmt.Sprintf("Undefined property '%s'", e.Method.Lexeme),
+				Code: CodeProperty,
 			}
 		}
 		return method.Bind(obj), nil
@@ -206,7 +291,7 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 				return nil, err
 			}
 			return -r, nil
-		case token.BANG:
+		case token.BANG, token.NOT:
 			return !i.isTruthy(rhs), nil
 		}
 		// unreachable
@@ -220,6 +305,9 @@ func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 			}
 			items = append(items, e)
 		}
+		if err := i.allocElems(e.Sqr, len(items)); err != nil {
+			return nil, err
+		}
 		return &LoxArray{items}, nil
 	case *ast.Logical:
 		lhs, err := i.evaluate(e.Left)
@@ -257,8 +345,9 @@ func (i *Interpreter) execute(stmt ast.Stmt) (any, error) {
 			}
 			if _, ok := supercls.(*UserClass); !ok {
 				return nil, &RunTimeErr{
-					Tok: s.Superclass.Name,
-					Msg: "Superclass must be a class",
+					Tok:   s.Superclass.Name,
+					Msg:   "Superclass must be a class",
+					Class: ClsTypeError,
 				}
 			}
 		}
@@ -300,33 +389,89 @@ func (i *Interpreter) execute(stmt ast.Stmt) (any, error) {
 			return nil, err
 		}
 		for i.isTruthy(cond) {
+			if err = i.step(s.Keyword); err != nil {
+				return nil, err
+			}
 			_, err = i.execute(s.Body)
-			if err != nil {
-				if errors.Is(err, BreakErr) {
-					return nil, nil
-				}
+			if stop, err := endIteration(err, s.Label); stop {
 				return nil, err
 			}
+			if s.Increment != nil {
+				if _, err = i.evaluate(s.Increment); err != nil {
+					return nil, err
+				}
+			}
 			cond, err = i.evaluate(s.Condition)
 			if err != nil {
 				return nil, err
 			}
 		}
 		return nil, nil
+	case *ast.ForIn:
+		obj, err := i.evaluate(s.Iterable)
+		if err != nil {
+			return nil, err
+		}
+		_, isMap := obj.(*LoxHashMap)
+		body := []ast.Stmt{s.Body}
+		err = i.forEach(s.Keyword, obj, func(key, val any) error {
+			if err := i.step(s.Keyword); err != nil {
+				return err
+			}
+			// every iteration gets new variables, so closures made in
+			// the body keep the values from their iteration
+			env := NewEnv(i.env)
+			switch {
+			case len(s.Vars) == 2:
+				env.Define(s.Vars[0].Lexeme, key)
+				env.Define(s.Vars[1].Lexeme, val)
+			case isMap:
+				env.Define(s.Vars[0].Lexeme, key)
+			default:
+				env.Define(s.Vars[0].Lexeme, val)
+			}
+			_, err := i.executeBlock(body, env)
+			if stop, _ := endIteration(err, s.Label); !stop {
+				return nil
+			}
+			return err
+		})
+		_, err = endIteration(err, s.Label)
+		return nil, err
 	case *ast.Function:
 		name := s.Name.Lexeme
 		i.env.Define(name, NewUserFn(name, s, i.env))
 		return nil, nil
+	case *ast.Import:
+		mod, err := i.importModule(s)
+		if err != nil {
+			return nil, err
+		}
+		if s.Name != nil {
+			i.env.Define(s.Name.Lexeme, mod)
+		}
+		for _, name := range s.Names {
+			val, err = mod.Get(name)
+			if err != nil {
+				return nil, err
+			}
+			i.env.Define(name.Lexeme, val)
+		}
+		return nil, nil
 	case *ast.Print:
 		val, err = i.evaluate(s.Expression)
 		if err != nil {
 			return nil, err
 		}
-		fmt.Println(i.stringify(val))
+		fmt.Fprintln(i.Stdout, i.stringify(val))
 		return nil, nil
 	case *ast.Control:
-		if s.Keyword.Kind == token.BREAK {
-			return nil, BreakErr
+		if s.Keyword.Kind == token.BREAK || s.Keyword.Kind == token.CONTINUE {
+			be := &BranchErr{Kind: s.Keyword.Kind}
+			if s.Label != nil {
+				be.Label = s.Label.Lexeme
+			}
+			return nil, be
 		}
 		if s.Value != nil {
 			val, err = i.evaluate(s.Value)
@@ -334,7 +479,54 @@ func (i *Interpreter) execute(stmt ast.Stmt) (any, error) {
 				return nil, err
 			}
 		}
+		if s.Keyword.Kind == token.THROW {
+			return nil, i.throw(s.Keyword, val)
+		}
 		return nil, &ReturnErr{Value: val}
+	case *ast.Switch:
+		val, err = i.evaluate(s.Value)
+		if err != nil {
+			return nil, err
+		}
+		body := s.Default
+	cases:
+		for _, c := range s.Cases {
+			for _, expr := range c.Values {
+				cval, err := i.evaluate(expr)
+				if err != nil {
+					return nil, err
+				}
+				if i.isEqual(val, cval) {
+					body = c.Body
+					break cases
+				}
+			}
+		}
+		if body == nil {
+			return nil, nil
+		}
+		_, err = i.executeBlock(body, NewEnv(i.env))
+		// a plain `break` leaves the switch, anything else is for a loop
+		if be, ok := err.(*BranchErr); ok && be.Kind == token.BREAK && be.Label == "" {
+			return nil, nil
+		}
+		return nil, err
+	case *ast.Try:
+		_, err = i.executeBlock(s.Body, NewEnv(i.env))
+		if rte, ok := err.(*RunTimeErr); ok && s.Catch != nil && rte.catchable() {
+			env := NewEnv(i.env)
+			if s.CatchName != nil {
+				env.Define(s.CatchName.Lexeme, i.errorValue(rte))
+			}
+			_, err = i.executeBlock(s.Catch, env)
+		}
+		if s.Finally != nil {
+			// errors in finally replace the one from the try or catch
+			if _, ferr := i.executeBlock(s.Finally, NewEnv(i.env)); ferr != nil {
+				return nil, ferr
+			}
+		}
+		return nil, err
 	case *ast.Var:
 		if s.Initializer != nil {
 			val, err = i.evaluate(s.Initializer)
@@ -349,6 +541,96 @@ func (i *Interpreter) execute(stmt ast.Stmt) (any, error) {
 	}
 }
 
+// step counts a step towards MaxSteps, and stops the script if it has run
+// out of steps or its context is done
+func (i *Interpreter) step(tok *token.Token) error {
+	i.steps++
+	if i.MaxSteps > 0 && i.steps > i.MaxSteps {
+		return &RunTimeErr{
+			Tok:  tok,
+			Msg:  fmt.Sprintf("Script ran for more than %d steps", i.MaxSteps),
+			Code: CodeStepLimit,
+			Err:  ErrStepLimit,
+		}
+	}
+	if err := i.ctx.Err(); err != nil {
+		return &RunTimeErr{
+			Tok:  tok,
+			Msg:  fmt.Sprintf("Script was stopped: %s", context.Cause(i.ctx)),
+			Code: CodeStopped,
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+// call calls fn from tok with a new frame on the call stack. runtime errors
+// get the traceback of the frames they went through
+func (i *Interpreter) call(fn LoxCallable, args []any, tok *token.Token) (any, error) {
+	if err := i.step(tok); err != nil {
+		return nil, err
+	}
+	if i.MaxDepth > 0 && len(i.frames) >= i.MaxDepth {
+		return nil, &RunTimeErr{Tok: tok, Msg: "Stack overflow", Code: CodeOverflow}
+	}
+	i.callTok = tok
+	i.frames = append(i.frames, diag.Frame{Name: frameName(fn), Tok: tok})
+	res, err := fn.Call(args...)
+	return res, i.popFrame(err, tok)
+}
+
+// popFrame pops the frame pushed for the call at tok, giving err the
+// traceback if it doesn't have one yet
+func (i *Interpreter) popFrame(err error, tok *token.Token) error {
+	if err != nil {
+		rte, ok := err.(*RunTimeErr)
+		if !ok {
+			// natives can return plain errors
+			rte = &RunTimeErr{Tok: tok, Msg: err.Error(), Err: err}
+		}
+		if rte.Trace == nil {
+			rte.Trace = i.traceback(rte.Tok)
+		}
+		err = rte
+	}
+	i.frames = i.frames[:len(i.frames)-1]
+	return err
+}
+
+// traceback returns the call stack of an error at tok. each frame holds
+// where its function was, which is where the next frame was called from
+func (i *Interpreter) traceback(tok *token.Token) []diag.Frame {
+	frames := i.frames
+	if n := len(frames); n != 0 && frames[n-1].Tok == tok {
+		// the error came from the callee itself, like a native failing,
+		// so its frame would just repeat the call
+		frames = frames[:n-1]
+	}
+	trace := make([]diag.Frame, 0, len(frames)+1)
+	name := "<script>"
+	for _, fr := range frames {
+		trace = append(trace, diag.Frame{Name: name, Tok: fr.Tok})
+		name = fr.Name
+	}
+	return append(trace, diag.Frame{Name: name, Tok: tok})
+}
+
+func frameName(fn LoxCallable) string {
+	switch fn := fn.(type) {
+	case *UserFn:
+		if fn.Func.Kind == ast.FN_LAMBDA {
+			return "<lambda>"
+		}
+		return fn.Name
+	case *UserClass:
+		return fn.Name
+	case *GoFn:
+		return fn.Name
+	default:
+		return fmt.Sprint(fn)
+	}
+}
+
 func (i *Interpreter) executeBlock(stmts []ast.Stmt, env *Env) (any, error) {
 	prv := i.env
 	defer func() { i.env = prv }()
@@ -387,8 +669,8 @@ func (i *Interpreter) evalAssign(expr *ast.Assign) (any, error) {
 		}
 		i.tmpBin.Left = &ast.Literal{Value: tmp}
 		i.tmpBin.Right = &ast.Literal{Value: val}
+		*i.tmpBin.Operator = *expr.Operator
 		i.tmpBin.Operator.Kind = oprType
-		i.tmpBin.Operator.Line = expr.Operator.Line
 		val, err = i.evalBinary(i.tmpBin)
 		if err != nil {
 			return nil, err
@@ -420,6 +702,8 @@ func (i *Interpreter) evalBinary(expr *ast.Binary) (any, error) {
 		return !i.isEqual(lhs, rhs), nil
 	case token.EQ_EQ:
 		return i.isEqual(lhs, rhs), nil
+	case token.IN:
+		return i.contains(expr.Operator, lhs, rhs)
 	case token.GT:
 		l, r, err := i.checkNumberOperands(expr.Operator, lhs, rhs)
 		if err != nil {
@@ -461,6 +745,9 @@ func (i *Interpreter) evalBinary(expr *ast.Binary) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		if int(r) == 0 {
+			return nil, &RunTimeErr{Tok: expr.Operator, Msg: "Modulo by 0", Class: ClsZeroDivisionError}
+		}
 		return float64(int(l) % int(r)), nil
 	case token.SLASH:
 		l, r, err := i.checkNumberOperands(expr.Operator, lhs, rhs)
@@ -468,7 +755,7 @@ func (i *Interpreter) evalBinary(expr *ast.Binary) (any, error) {
 			return nil, err
 		}
 		if r == 0.0 {
-			return nil, &RunTimeErr{Tok: expr.Operator, Msg: "Division by 0"}
+			return nil, &RunTimeErr{Tok: expr.Operator, Msg: "Division by 0", Class: ClsZeroDivisionError}
 		}
 		return l / r, nil
 	case token.PLUS:
@@ -481,12 +768,13 @@ func (i *Interpreter) evalBinary(expr *ast.Binary) (any, error) {
 		}
 		if l, ok := lhs.(string); ok {
 			if r, ok := rhs.(string); ok {
-				return l + r, nil
+				return i.concat(expr.Operator, l, r)
 			}
 		}
 		return nil, &RunTimeErr{
-			Tok: expr.Operator,
-			Msg: "Operands must be two numbers or two strings",
+			Tok:  expr.Operator,
+			Msg:  "Operands must be two numbers or two strings",
+			Code: CodeOperand,
 		}
 	}
 	// unreachable
@@ -518,11 +806,15 @@ func (i *Interpreter) evalIndexGet(expr *ast.IndexedGet) (any, error) {
 		var val any
 		if isRange {
 			val, err = iter.IndexRange(start, stop)
+			if arr, ok := val.(*LoxArray); ok && err == nil {
+				// a slice is a new array as far as the script can tell
+				err = i.allocElems(expr.Sqr, len(arr.Items))
+			}
 		} else {
 			val, err = iter.IndexGet(start)
 		}
 		if err != nil {
-			return nil, &RunTimeErr{Tok: expr.Sqr, Msg: err.Error()}
+			return nil, at(err, expr.Sqr, CodeIndex)
 		}
 		return val, nil
 	case string:
@@ -543,11 +835,15 @@ func (i *Interpreter) evalIndexGet(expr *ast.IndexedGet) (any, error) {
 		if isRange {
 			return iter[start:stop], nil
 		}
-		return string(iter[start]), nil
+		// indexes are byte offsets, the character that starts there is
+		// returned whole
+		r, _ := utf8.DecodeRuneInString(iter[start:])
+		return string(r), nil
 	default:
 		return nil, &RunTimeErr{
-			Tok: expr.Sqr,
-			Msg: "Can only index an iterable type",
+			Tok:   expr.Sqr,
+			Msg:   "Can only index an iterable type",
+			Class: ClsTypeError,
 		}
 	}
 }
@@ -566,8 +862,9 @@ func (i *Interpreter) hashable(obj any, brace *token.Token) error {
 		return nil
 	default:
 		return &RunTimeErr{
-			Tok: brace,
-			Msg: fmt.Sprintf("Unhashable type '%T'", val),
+			Tok:   brace,
+			Msg:   fmt.Sprintf("Unhashable type '%T'", val),
+			Class: ClsTypeError,
 		}
 	}
 }
@@ -580,8 +877,10 @@ func (i *Interpreter) checkIndex(sqr *token.Token, index ast.Expr, cnt int, kind
 	idx, err := i.checkInt(fdx)
 	if err != nil {
 		return 0, &RunTimeErr{
-			Tok: sqr,
-			Msg: fmt.Sprintf("Can only use integers to index an %s", kind),
+			Tok:   sqr,
+			Msg:   fmt.Sprintf("Can only use integers to index an %s", kind),
+			Code:  CodeIndex,
+			Class: ClsTypeError,
 		}
 	}
 	ogIdx := idx
@@ -593,8 +892,10 @@ func (i *Interpreter) checkIndex(sqr *token.Token, index ast.Expr, cnt int, kind
 		return idx, nil
 	}
 	return 0, &RunTimeErr{
-		Tok: sqr,
-		Msg: fmt.Sprintf("Index out of bounds. index: %d, length: %d", ogIdx, cnt),
+		Tok:   sqr,
+		Msg:   fmt.Sprintf("Index out of bounds. index: %d, length: %d", ogIdx, cnt),
+		Code:  CodeIndex,
+		Class: ClsIndexError,
 	}
 }
 
@@ -646,7 +947,7 @@ func (i *Interpreter) checkNumberOperand(oprtr *token.Token, opr any) (float64,
 	if r, ok := opr.(float64); ok {
 		return r, nil
 	}
-	return 0, &RunTimeErr{Tok: oprtr, Msg: "Operand must be a number"}
+	return 0, &RunTimeErr{Tok: oprtr, Msg: "Operand must be a number", Code: CodeOperand}
 }
 
 func (i *Interpreter) checkNumberOperands(oprtr *token.Token, lhs any, rhs any) (float64, float64, error) {
@@ -655,10 +956,14 @@ func (i *Interpreter) checkNumberOperands(oprtr *token.Token, lhs any, rhs any)
 			return l, r, nil
 		}
 	}
-	return 0, 0, &RunTimeErr{Tok: oprtr, Msg: "Operands must be a number"}
+	return 0, 0, &RunTimeErr{Tok: oprtr, Msg: "Operands must be a number", Code: CodeOperand}
 }
 
 func (i *Interpreter) reportRunTimeErr(msg error) {
-	fmt.Fprintln(os.Stderr, msg)
+	if rte, ok := msg.(*RunTimeErr); ok {
+		i.diags.Report(rte.Diagnostic())
+	} else {
+		i.diags.Report(&diag.Diagnostic{Severity: diag.SEV_ERROR, Code: CodeRunTime, Msg: msg.Error()})
+	}
 	i.CurErr = msg
 }
@@ -0,0 +1,212 @@
+package interpreter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// forEach calls body with each key and value of obj, which are the index
+// and item for arrays, strings and instances. it stops at the first error
+// body returns
+func (i *Interpreter) forEach(tok *token.Token, obj any, body func(key, val any) error) error {
+	switch obj := obj.(type) {
+	case *LoxArray:
+		// the length is checked every time, so items pushed in the loop are seen
+		for idx := 0; idx < len(obj.Items); idx++ {
+			if err := body(float64(idx), obj.Items[idx]); err != nil {
+				return err
+			}
+		}
+	case string:
+		// the keys are byte offsets, the same as indexing a string uses
+		for idx, r := range obj {
+			if err := body(float64(idx), string(r)); err != nil {
+				return err
+			}
+		}
+	case *LoxHashMap:
+		for _, key := range sortedKeys(obj.Pairs) {
+			val, ok := obj.Pairs[key]
+			if !ok {
+				// deleted by an earlier iteration
+				continue
+			}
+			if err := body(key, val); err != nil {
+				return err
+			}
+		}
+	case *LoxInstance:
+		return i.forEachInstance(tok, obj, body)
+	default:
+		return &RunTimeErr{
+			Tok:   tok,
+			Msg:   fmt.Sprintf("Can only loop over arrays, strings, hashmaps and instances, got %s", typeName(obj)),
+			Class: ClsTypeError,
+		}
+	}
+	return nil
+}
+
+// forEachInstance loops over an instance using its `iter()` method, which
+// returns an iterator. the loop calls the iterator's `next()` until its
+// `hasNext()` returns false, or if it has no `hasNext()`, until `next()`
+// returns nil
+func (i *Interpreter) forEachInstance(tok *token.Token, obj *LoxInstance, body func(key, val any) error) error {
+	iter, err := i.callMethod(tok, obj, "iter")
+	if err != nil {
+		return err
+	}
+	iterInst, ok := iter.(*LoxInstance)
+	if !ok {
+		return &RunTimeErr{
+			Tok:   tok,
+			Msg:   fmt.Sprintf("'iter' must return an instance, got %s", typeName(iter)),
+			Class: ClsTypeError,
+		}
+	}
+	_, sentinel := iterInst.Fields["hasNext"]
+	sentinel = !sentinel && iterInst.Klass.FindMethod("hasNext") == nil
+	for idx := 0; ; idx++ {
+		if !sentinel {
+			more, err := i.callMethod(tok, iterInst, "hasNext")
+			if err != nil {
+				return err
+			}
+			if !i.isTruthy(more) {
+				return nil
+			}
+		}
+		val, err := i.callMethod(tok, iterInst, "next")
+		if err != nil {
+			return err
+		}
+		if sentinel && val == nil {
+			return nil
+		}
+		if err := body(float64(idx), val); err != nil {
+			return err
+		}
+	}
+}
+
+// callMethod calls `obj.name()` for the loop at tok
+func (i *Interpreter) callMethod(tok *token.Token, obj *LoxInstance, name string) (any, error) {
+	nameTok := token.NewToken(token.IDENTIFIER, name, nil, tok.Line)
+	method, err := obj.Get(&nameTok)
+	if err != nil {
+		article := "a"
+		if strings.ContainsRune("aeiou", rune(name[0])) {
+			article = "an"
+		}
+		return nil, &RunTimeErr{
+			Tok:   tok,
+			Msg:   fmt.Sprintf("Can only loop over instances with %s '%s' method, %s has none", article, name, obj),
+			Code:  CodeProperty,
+			Class: ClsTypeError,
+		}
+	}
+	fn, ok := method.(LoxCallable)
+	if !ok {
+		return nil, &RunTimeErr{
+			Tok:   tok,
+			Msg:   fmt.Sprintf("'%s' must be a method, got %s", name, typeName(method)),
+			Class: ClsTypeError,
+		}
+	}
+	if fn.Arity() != -1 && fn.Arity() != 0 {
+		msg := fmt.Sprintf("'%s' must take no arguments, it takes %d", name, fn.Arity())
+		return nil, &RunTimeErr{Tok: tok, Msg: msg, Code: CodeArity}
+	}
+	return i.call(fn, []any{i}, tok)
+}
+
+// contains returns the result of `elem in coll`
+func (i *Interpreter) contains(tok *token.Token, elem, coll any) (bool, error) {
+	switch coll := coll.(type) {
+	case *LoxArray:
+		return slices.ContainsFunc(coll.Items, func(item any) bool { return i.isEqual(elem, item) }), nil
+	case string:
+		sub, ok := elem.(string)
+		if !ok {
+			return false, &RunTimeErr{
+				Tok:  tok,
+				Msg:  fmt.Sprintf("Can only look for strings in a string, got %s", typeName(elem)),
+				Code: CodeOperand,
+			}
+		}
+		return strings.Contains(coll, sub), nil
+	case *LoxHashMap:
+		if err := Hashable(elem); err != nil {
+			return false, at(err, tok, CodeOperand)
+		}
+		_, ok := coll.Pairs[elem]
+		return ok, nil
+	case *LoxInstance:
+		name, ok := elem.(string)
+		if !ok {
+			return false, &RunTimeErr{
+				Tok:  tok,
+				Msg:  fmt.Sprintf("Field names must be strings, got %s", typeName(elem)),
+				Code: CodeOperand,
+			}
+		}
+		_, ok = coll.Fields[name]
+		return ok, nil
+	}
+	return false, &RunTimeErr{
+		Tok:  tok,
+		Msg:  fmt.Sprintf("Can only use 'in' with arrays, strings, hashmaps and instances, got %s", typeName(coll)),
+		Code: CodeOperand,
+	}
+}
+
+// sortedKeys returns the keys of a hashmap in a stable order, so that
+// looping over a hashmap always goes in the same order
+func sortedKeys(pairs map[any]any) []any {
+	keys := make([]any, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	slices.SortStableFunc(keys, compareKeys)
+	return keys
+}
+
+// compareKeys orders nil, then bools, numbers, strings, and instances
+func compareKeys(a, b any) int {
+	if c := cmp.Compare(keyRank(a), keyRank(b)); c != 0 {
+		return c
+	}
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		} else if !a {
+			return -1
+		}
+		return 1
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+func keyRank(key any) int {
+	switch key.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,69 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// ErrMemLimit is wrapped by the *RunTimeErr returned when a script
+// allocates more than Interpreter.Limits allows
+var ErrMemLimit = errors.New("memory limit exceeded")
+
+// Limits caps how much a script can allocate, so that a script can't run
+// its host out of memory. the counts are of everything allocated during a
+// single run, they start again from 0 every time the interpreter runs a
+// script, so the values a REPL keeps between lines aren't counted again.
+// a field that is 0 means there is no limit
+type Limits struct {
+	// elements in all arrays, counting array literals, slices of arrays,
+	// `push`, and Go slices and arrays converted to lox
+	ArrayElems int
+	// entries in all hashmaps, counting hashmap literals, new keys, and Go
+	// maps converted to lox
+	HashEntries int
+	// length of the longest string `+` can make
+	StringLen int
+	// class instances
+	Instances int
+}
+
+// alloc adds n to *used, and fails if that puts it over limit
+func (i *Interpreter) alloc(tok *token.Token, used *int, limit, n int, what string) error {
+	if limit > 0 && *used+n > limit {
+		return &RunTimeErr{
+			Tok:  tok,
+			Msg:  fmt.Sprintf("Memory limit exceeded: more than %d %s", limit, what),
+			Code: CodeMemLimit,
+			Err:  ErrMemLimit,
+		}
+	}
+	*used += n
+	return nil
+}
+
+func (i *Interpreter) allocElems(tok *token.Token, n int) error {
+	return i.alloc(tok, &i.allocs.ArrayElems, i.Limits.ArrayElems, n, "array elements")
+}
+
+func (i *Interpreter) allocEntries(tok *token.Token, n int) error {
+	return i.alloc(tok, &i.allocs.HashEntries, i.Limits.HashEntries, n, "hashmap entries")
+}
+
+func (i *Interpreter) allocInstance(tok *token.Token) error {
+	return i.alloc(tok, &i.allocs.Instances, i.Limits.Instances, 1, "instances")
+}
+
+// concat joins 2 strings for `+`
+func (i *Interpreter) concat(tok *token.Token, l, r string) (string, error) {
+	if i.Limits.StringLen > 0 && len(l)+len(r) > i.Limits.StringLen {
+		return "", &RunTimeErr{
+			Tok:  tok,
+			Msg:  fmt.Sprintf("Memory limit exceeded: strings can't be longer than %d bytes", i.Limits.StringLen),
+			Code: CodeMemLimit,
+			Err:  ErrMemLimit,
+		}
+	}
+	return l + r, nil
+}
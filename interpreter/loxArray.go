@@ -13,7 +13,10 @@ func (la *LoxArray) String() string {
 func (la *LoxArray) checkIndex(index any) (int, error) {
 	fidx, ok := index.(float64)
 	if !ok {
-		return -1, fmt.Errorf("can only use numbers to index arrays got '%s'", index)
+		return -1, &RunTimeErr{
+			Msg:   fmt.Sprintf("Can only use numbers to index arrays got '%v'", index),
+			Class: ClsTypeError,
+		}
 	}
 	idx := int(fidx)
 	ogidx := idx
@@ -23,7 +26,10 @@ func (la *LoxArray) checkIndex(index any) (int, error) {
 	if idx >= 0 && idx < len(la.Items) {
 		return idx, nil
 	}
-	return -1, fmt.Errorf("index out of bounds. index: %d, length: %d", ogidx, len(la.Items))
+	return -1, &RunTimeErr{
+		Msg:   fmt.Sprintf("Index out of bounds. index: %d, length: %d", ogidx, len(la.Items)),
+		Class: ClsIndexError,
+	}
 }
 
 func (la *LoxArray) IndexGet(index any) (any, error) {
@@ -27,6 +27,10 @@ func (lc *UserClass) String() string {
 }
 
 func (lc *UserClass) Call(args ...any) (any, error) {
+	intprt := args[0].(*Interpreter)
+	if err := intprt.allocInstance(intprt.callTok); err != nil {
+		return nil, err
+	}
 	inst := NewLoxInstance(lc)
 	init := lc.FindMethod("init")
 	if init != nil {
@@ -0,0 +1,112 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// the names of the built in error classes, runtime errors are caught as
+// one of them
+const (
+	ClsError             = "Error"
+	ClsTypeError         = "TypeError"
+	ClsIndexError        = "IndexError"
+	ClsKeyError          = "KeyError"
+	ClsZeroDivisionError = "ZeroDivisionError"
+)
+
+// defineErrorClasses defines `Error` and its subclasses as builtins.
+// Error's init is built by hand, it is the same as:
+//
+//	class Error {
+//	  init(message) { this.message = message; }
+//	}
+//	class TypeError < Error {}
+//	...
+func (i *Interpreter) defineErrorClasses() {
+	name := token.NewToken(token.IDENTIFIER, "init", nil, -1)
+	msg := token.NewToken(token.IDENTIFIER, "message", nil, -1)
+	this := token.NewToken(token.THIS, "this", nil, -1)
+	thisExpr, msgExpr := &ast.This{Keyword: &this}, &ast.Variable{Name: &msg}
+	// the body runs in the env of the params, which is inside the env of `this`
+	i.Resolve(thisExpr, 1)
+	i.Resolve(msgExpr, 0)
+	init := &ast.Function{
+		Name:   &name,
+		Params: []*token.Token{&msg},
+		Body: []ast.Stmt{&ast.Expression{
+			Expression: &ast.Set{Object: thisExpr, Name: &msg, Value: msgExpr},
+		}},
+		Kind: ast.FN_INIT,
+	}
+	methods := map[string]*UserFn{"init": NewUserFn("init", init, i.builtins)}
+	base := NewUserClass(ClsError, nil, methods)
+	i.errClasses[ClsError] = base
+	i.builtins.Define(ClsError, base)
+	for _, name := range []string{ClsTypeError, ClsIndexError, ClsKeyError, ClsZeroDivisionError} {
+		i.errClasses[name] = NewUserClass(name, base, make(map[string]*UserFn))
+		i.builtins.Define(name, i.errClasses[name])
+	}
+}
+
+// isError reports whether val is an instance of Error or one of its subclasses
+func (i *Interpreter) isError(val any) bool {
+	inst, ok := val.(*LoxInstance)
+	if !ok {
+		return false
+	}
+	for klass := inst.Klass; klass != nil; klass = klass.SuperClass {
+		if klass == i.errClasses[ClsError] {
+			return true
+		}
+	}
+	return false
+}
+
+// throw makes the error for `throw val`. errors get their line and stack
+// from where they were first thrown, and their kind, the name of their
+// class, so that catch clauses can tell them apart
+func (i *Interpreter) throw(keyword *token.Token, val any) *RunTimeErr {
+	msg := fmt.Sprintf("Uncaught %v", val)
+	if i.isError(val) {
+		inst := val.(*LoxInstance)
+		if _, ok := inst.Fields["stack"]; !ok {
+			inst.Fields["kind"] = inst.Klass.Name
+			inst.Fields["line"] = float64(keyword.Line)
+			inst.Fields["stack"] = stack(i.traceback(keyword))
+		}
+		msg = fmt.Sprintf("%s: %v", inst.Klass.Name, inst.Fields["message"])
+	}
+	return &RunTimeErr{Tok: keyword, Msg: msg, Code: CodeThrown, Value: val, thrown: true}
+}
+
+// errorValue returns what a catch clause binds for err, errors from the
+// interpreter itself are made into Error instances the first time
+func (i *Interpreter) errorValue(err *RunTimeErr) any {
+	if err.thrown {
+		return err.Value
+	}
+	if err.Trace == nil {
+		// it hasn't left the function it happened in yet
+		err.Trace = i.traceback(err.Tok)
+	}
+	inst := NewLoxInstance(i.errClasses[err.class()])
+	inst.Fields["message"] = err.Msg
+	inst.Fields["kind"] = inst.Klass.Name
+	inst.Fields["line"] = nil
+	if err.Tok != nil {
+		inst.Fields["line"] = float64(err.Tok.Line)
+	}
+	inst.Fields["stack"] = stack(err.Trace)
+	err.Value, err.thrown = inst, true
+	return inst
+}
+
+// stack returns the value of an error's stack field
+func stack(trace []diag.Frame) string {
+	return strings.TrimSuffix(diag.Traceback(trace), "\n")
+}
@@ -30,7 +30,11 @@ func (fn *UserFn) Call(args ...any) (any, error) {
 	for i, param := range fn.Func.Params {
 		env.Define(param.Lexeme, args[i])
 	}
+	// globals are looked up in the module the function was defined in
+	prvGlobals := intprt.Globals
+	intprt.Globals = intprt.moduleEnv(fn.Closure)
 	_, err := intprt.executeBlock(fn.Func.Body, env)
+	intprt.Globals = prvGlobals
 	if err != nil {
 		switch e := err.(type) {
 		case *ReturnErr:
@@ -19,7 +19,7 @@ func Hashable(obj any) error {
 	case *LoxInstance:
 		return nil
 	default:
-		return fmt.Errorf("unhashable type '%T'", obj)
+		return &RunTimeErr{Msg: fmt.Sprintf("Unhashable type '%T'", obj), Class: ClsTypeError}
 	}
 }
 
@@ -55,7 +55,7 @@ func (lhm *LoxHashMap) IndexGet(index any) (any, error) {
 	if val, ok := lhm.Pairs[index]; ok {
 		return val, nil
 	}
-	return nil, fmt.Errorf("key '%s' not present", index)
+	return nil, &RunTimeErr{Msg: fmt.Sprintf("Key '%v' not present", index), Class: ClsKeyError}
 }
 
 func (lhm *LoxHashMap) IndexRange(start, stop any) (any, error) {
@@ -3,6 +3,7 @@ package interpreter
 import (
 	"fmt"
 
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/token"
 )
 
@@ -27,12 +28,24 @@ func (li *LoxInstance) Get(name *token.Token) (any, error) {
 	if method != nil {
 		return method.Bind(li), nil
 	}
+	names := make([]string, 0, len(li.Fields))
+	for field := range li.Fields {
+		names = append(names, field)
+	}
+	for klass := li.Klass; klass != nil; klass = klass.SuperClass {
+		for method := range klass.Methods {
+			names = append(names, method)
+		}
+	}
 	return nil, &RunTimeErr{
-		Tok: name,
-		Msg: fmt.Sprintf("Undefined property '%s'", name.Lexeme),
+		Tok:  name,
+		Msg:  fmt.Sprintf("Undefined property '%s'", name.Lexeme),
+		Code: CodeProperty,
+		Help: diag.Suggest(name.Lexeme, names),
 	}
 }
 
-func (li *LoxInstance) Set(name *token.Token, val any) {
+func (li *LoxInstance) Set(name *token.Token, val any) error {
 	li.Fields[name.Lexeme] = val
+	return nil
 }
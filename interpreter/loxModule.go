@@ -0,0 +1,38 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// LoxModule is a namespace of values, its members are read using `mod.name`
+type LoxModule struct {
+	Name    string
+	Members map[string]any
+}
+
+func NewLoxModule(name string, members map[string]any) *LoxModule {
+	return &LoxModule{name, members}
+}
+
+func (lm *LoxModule) Get(name *token.Token) (any, error) {
+	if val, ok := lm.Members[name.Lexeme]; ok {
+		return val, nil
+	}
+	names := make([]string, 0, len(lm.Members))
+	for member := range lm.Members {
+		names = append(names, member)
+	}
+	return nil, &RunTimeErr{
+		Tok:  name,
+		Msg:  fmt.Sprintf("Module '%s' has no member '%s'", lm.Name, name.Lexeme),
+		Code: CodeProperty,
+		Help: diag.Suggest(name.Lexeme, names),
+	}
+}
+
+func (lm *LoxModule) String() string {
+	return fmt.Sprintf("<module %s>", lm.Name)
+}
@@ -0,0 +1,15 @@
+package interpreter
+
+import "github.com/Subarctic2796/gojlox/token"
+
+// LoxGetter is implemented by values with properties that can be read
+// using `obj.name`
+type LoxGetter interface {
+	Get(name *token.Token) (any, error)
+}
+
+// LoxSetter is implemented by values with properties that can be set
+// using `obj.name = val`
+type LoxSetter interface {
+	Set(name *token.Token, val any) error
+}
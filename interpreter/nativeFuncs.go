@@ -54,7 +54,7 @@ func (ParseNumFn) String() string { return "<native fn parseNum>" }
 type PrintFn struct{}
 
 func (PrintFn) Call(args ...any) (any, error) {
-	fmt.Println(args[1:]...)
+	fmt.Fprintln(args[0].(*Interpreter).Stdout, args[1:]...)
 	return nil, nil
 }
 
@@ -84,6 +84,10 @@ type ArrPushFn struct{}
 func (ArrPushFn) Call(args ...any) (any, error) {
 	switch arr := args[1].(type) {
 	case *LoxArray:
+		intprt := args[0].(*Interpreter)
+		if err := intprt.allocElems(intprt.callTok, 1); err != nil {
+			return nil, err
+		}
 		arr.Items = append(arr.Items, args[2])
 		return arr, nil
 	default:
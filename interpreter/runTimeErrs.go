@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/token"
 )
 
@@ -15,16 +16,137 @@ func (re *ReturnErr) Error() string {
 	return "Return Error"
 }
 
-var (
-	BreakErr        = errors.New("Break Error")
-	RangeHashMapErr = errors.New("can't use ranges on hashmaps")
+// BranchErr is returned by `break` and `continue` to unwind to the loop
+// they are for
+type BranchErr struct {
+	// token.BREAK or token.CONTINUE
+	Kind token.TokenType
+	// empty for the innermost loop
+	Label string
+}
+
+func (be *BranchErr) Error() string {
+	return "Branch Error"
+}
+
+// endIteration handles err, returned by the body of the loop with label.
+// it returns whether the loop should stop, and the error the loop returns
+func endIteration(err error, label *token.Token) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	be, ok := err.(*BranchErr)
+	if !ok || (be.Label != "" && (label == nil || be.Label != label.Lexeme)) {
+		return true, err
+	}
+	return be.Kind == token.BREAK, nil
+}
+
+var RangeHashMapErr = errors.New("can't use ranges on hashmaps")
+
+// codes used in RunTimeErr.Code, errors without a code use CodeRunTime
+const (
+	CodeRunTime   = "R0001"
+	CodeUndefined = "R0002"
+	CodeProperty  = "R0003"
+	CodeOperand   = "R0004"
+	CodeArity     = "R0005"
+	CodeIndex     = "R0006"
+	CodeArgument  = "R0007"
+	CodeStopped   = "R0008"
+	CodeStepLimit = "R0009"
+	CodeOverflow  = "R0010"
+	CodeMemLimit  = "R0011"
+	CodeThrown    = "R0012"
+	CodeImport    = "R0013"
 )
 
+// ErrStepLimit is wrapped by the *RunTimeErr returned when a script runs
+// for more than Interpreter.MaxSteps steps
+var ErrStepLimit = errors.New("step limit exceeded")
+
 type RunTimeErr struct {
-	Tok *token.Token
-	Msg string
+	// nil if the error didn't come from the script, like when calling
+	// a lox function from Go with the wrong number of arguments
+	Tok  *token.Token
+	Msg  string
+	Code string
+	// optional hint on how to fix the error
+	Help string
+	// optional cause of the error, like context.Canceled or ErrStepLimit
+	Err error
+	// the built in error class that catch clauses catch the error as,
+	// one of the Cls constants. if it is empty the class comes from Code
+	Class string
+	// the calls that led to the error, nil if it happened outside of
+	// any function
+	Trace []diag.Frame
+	// the value given to `throw`, or the Error instance a catch clause
+	// caught the error as
+	Value  any
+	thrown bool
+}
+
+// class returns the name of the built in error class e is caught as
+func (e *RunTimeErr) class() string {
+	if e.Class != "" {
+		return e.Class
+	}
+	switch e.Code {
+	case CodeOperand, CodeArity, CodeArgument:
+		return ClsTypeError
+	}
+	return ClsError
+}
+
+// at gives err, returned by a method of a value, the token it happened at
+func at(err error, tok *token.Token, code string) *RunTimeErr {
+	rte, ok := err.(*RunTimeErr)
+	if !ok {
+		return &RunTimeErr{Tok: tok, Msg: err.Error(), Code: code}
+	}
+	if rte.Tok == nil {
+		rte.Tok = tok
+	}
+	if rte.Code == "" {
+		rte.Code = code
+	}
+	return rte
+}
+
+// catchable reports whether a catch clause can catch e, errors that stop
+// the script for the host can't be caught
+func (e *RunTimeErr) catchable() bool {
+	switch e.Code {
+	case CodeStopped, CodeStepLimit, CodeMemLimit:
+		return false
+	}
+	return true
 }
 
 func (e *RunTimeErr) Error() string {
-	return fmt.Sprintf("[RunTimeError]: %s\n[line %d] %s", e.Msg, e.Tok.Line, e.Tok)
+	if e.Tok == nil {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Tok.Pos(), e.Msg)
+}
+
+func (e *RunTimeErr) Unwrap() error { return e.Err }
+
+// Diagnostic converts e so that it can be reported
+func (e *RunTimeErr) Diagnostic() *diag.Diagnostic {
+	d := &diag.Diagnostic{
+		Severity: diag.SEV_ERROR,
+		Code:     e.Code,
+		Tok:      e.Tok,
+		Msg:      e.Msg,
+		Trace:    e.Trace,
+	}
+	if d.Code == "" {
+		d.Code = CodeRunTime
+	}
+	if e.Help != "" {
+		d.Help = append(d.Help, e.Help)
+	}
+	return d
 }
@@ -3,9 +3,10 @@ package scanner
 import (
 	"errors"
 	"fmt"
-	"os"
 	"strconv"
+	"unicode/utf8"
 
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/token"
 )
 
@@ -15,34 +16,80 @@ var (
 	ErrUnterminatedComment = errors.New("unterminated comment")
 )
 
+var errCodes = map[error]string{
+	ErrUnexpectedChar:      "L0001",
+	ErrUnterminatedStr:     "L0002",
+	ErrUnterminatedComment: "L0003",
+}
+
+// LexErr is a lexical error, Err is one of the Err* values above
+// and Tok covers the source text that caused it
+type LexErr struct {
+	Err error
+	Tok *token.Token
+}
+
+func (e *LexErr) msg() string {
+	if e.Err == ErrUnexpectedChar {
+		return fmt.Sprintf("%s '%s'", e.Err, e.Tok.Lexeme)
+	}
+	return e.Err.Error()
+}
+
+func (e *LexErr) Error() string { return fmt.Sprintf("%s: %s", e.Tok.Pos(), e.msg()) }
+func (e *LexErr) Unwrap() error { return e.Err }
+
 type Lexer struct {
 	src              []rune
 	Tokens           []token.Token
 	start, cur, Line int
-	curErr           error
+	// every error found since the last Reset
+	Errs  []*LexErr
+	diags *diag.Reporter
+	// when set, comments are kept as `token.COMMENT` tokens instead of being
+	// thrown away. the parser doesn't understand them, so they have to be
+	// filtered out before parsing
+	KeepComments bool
+	// name of the file being scanned, it is used in token positions
+	Name string
+	file *token.File
+	// byte offsets of start and cur in the source
+	startOff, curOff int
+	// where the line that the current token starts on begins
+	startLine, startCol, lineStart int
 }
 
-func NewLexer(src string) *Lexer {
-	return &Lexer{[]rune(src), make([]token.Token, 0, 16), 0, 0, 1, nil}
+func NewLexer(src string, diags *diag.Reporter) *Lexer {
+	l := &Lexer{diags: diags}
+	l.Reset(src)
+	return l
 }
 
 func (l *Lexer) Reset(src string) {
 	l.src = []rune(src)
-	l.Tokens = make([]token.Token, 0)
+	l.file = &token.File{Name: l.Name, Src: src}
+	l.Tokens = make([]token.Token, 0, 16)
 	l.start, l.cur, l.Line = 0, 0, 1
-	l.curErr = nil
+	l.startOff, l.curOff = 0, 0
+	l.startLine, l.startCol, l.lineStart = 1, 1, 0
+	l.Errs = make([]*LexErr, 0)
 }
 
+// ScanTokens scans the whole source. it doesn't stop at errors, the tokens
+// are always returned so that the parser can still report its own errors.
+// the error joins every *LexErr in Errs
 func (l *Lexer) ScanTokens() ([]token.Token, error) {
 	for !l.isAtEnd() {
-		l.start = l.cur
+		l.markStart()
 		l.scanToken()
 	}
-	l.Tokens = append(l.Tokens, token.NewToken(token.EOF, "", nil, l.Line))
-	if l.curErr != nil {
-		return nil, l.curErr
+	l.markStart()
+	l.Tokens = append(l.Tokens, l.makeToken(token.EOF, nil))
+	errs := make([]error, 0, len(l.Errs))
+	for _, err := range l.Errs {
+		errs = append(errs, err)
 	}
-	return l.Tokens, nil
+	return l.Tokens, errors.Join(errs...)
 }
 
 func (l *Lexer) scanToken() {
@@ -88,14 +135,13 @@ func (l *Lexer) scanToken() {
 			for l.peek() != '\n' && !l.isAtEnd() {
 				l.advance()
 			}
+			l.addComment()
 		} else if l.match('*') {
 			l.multiLineComment()
 		} else {
 			l.addMatchToken('=', token.SLASH_EQ, token.SLASH)
 		}
-	case ' ', '\r', '\t':
-	case '\n':
-		l.Line++
+	case ' ', '\r', '\t', '\n':
 	case '"':
 		l.addString()
 	default:
@@ -113,9 +159,6 @@ func (l *Lexer) multiLineComment() {
 	nesting := 1
 	for nesting > 0 && !l.isAtEnd() {
 		p, pn := l.peek(), l.peekNext()
-		if p == '\n' || l.src[l.cur] == '\n' {
-			l.Line++
-		}
 		if p == '/' && pn == '*' {
 			l.advance()
 			l.advance()
@@ -130,11 +173,16 @@ func (l *Lexer) multiLineComment() {
 		}
 		l.advance()
 	}
-	if l.isAtEnd() {
+	if nesting > 0 {
 		l.report(ErrUnterminatedComment)
-		return
 	}
-	l.advance()
+	l.addComment()
+}
+
+func (l *Lexer) addComment() {
+	if l.KeepComments {
+		l.addToken(token.COMMENT)
+	}
 }
 
 func (l *Lexer) identifier() {
@@ -178,14 +226,13 @@ func (l *Lexer) peekNext() rune {
 
 func (l *Lexer) addString() {
 	for l.peek() != '"' && !l.isAtEnd() {
-		if l.peek() == '\n' {
-			l.Line++
-		}
 		l.advance()
 	}
 
 	if l.isAtEnd() {
 		l.report(ErrUnterminatedStr)
+		// still add the string so the parser doesn't report errors about it
+		l.addTokenWithLit(token.STRING, string(l.src[l.start+1:l.cur]))
 		return
 	}
 
@@ -209,7 +256,7 @@ func (l *Lexer) match(expected rune) bool {
 	if l.src[l.cur] != expected {
 		return false
 	}
-	l.cur++
+	l.advance()
 	return true
 }
 
@@ -223,23 +270,49 @@ func (l *Lexer) peek() rune {
 func (l *Lexer) isAtEnd() bool { return l.cur >= len(l.src) }
 
 func (l *Lexer) advance() rune {
+	c := l.src[l.cur]
 	l.cur++
-	return l.src[l.cur-1]
+	l.curOff += utf8.RuneLen(c)
+	if c == '\n' {
+		l.Line++
+		l.lineStart = l.cur
+	}
+	return c
+}
+
+// markStart records the position of the token that starts at cur
+func (l *Lexer) markStart() {
+	l.start, l.startOff = l.cur, l.curOff
+	l.startLine, l.startCol = l.Line, l.cur-l.lineStart+1
+}
+
+func (l *Lexer) makeToken(kind token.TokenType, lit any) token.Token {
+	return token.Token{
+		Kind:    kind,
+		Lexeme:  string(l.src[l.start:l.cur]),
+		Literal: lit,
+		Line:    l.startLine,
+		Col:     l.startCol,
+		Start:   l.startOff,
+		End:     l.curOff,
+		File:    l.file,
+	}
 }
 
 func (l *Lexer) addToken(kind token.TokenType) { l.addTokenWithLit(kind, nil) }
 
 func (l *Lexer) addTokenWithLit(kind token.TokenType, lit any) {
-	txt := string(l.src[l.start:l.cur])
-	l.Tokens = append(l.Tokens, token.NewToken(kind, txt, lit, l.Line))
+	l.Tokens = append(l.Tokens, l.makeToken(kind, lit))
 }
 
 func (l *Lexer) report(msg error) {
-	fullMsg := fmt.Sprintf("[line %d] [Lexer] Error: %s", l.Line, msg)
-	if errors.Is(msg, ErrUnexpectedChar) {
-		fmt.Fprintf(os.Stderr, "%s '%c'\n", fullMsg, l.src[l.cur-1])
-	} else {
-		fmt.Fprintln(os.Stderr, fullMsg)
-	}
-	l.curErr = msg
+	tok := l.makeToken(token.NONE, nil)
+	err := &LexErr{Err: msg, Tok: &tok}
+	l.diags.Report(&diag.Diagnostic{
+		Severity: diag.SEV_ERROR,
+		Code:     errCodes[msg],
+		Tok:      &tok,
+		Msg:      err.msg(),
+	})
+	l.Errs = append(l.Errs, err)
 }
@@ -0,0 +1,133 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Subarctic2796/gojlox/interpreter"
+)
+
+const callScript = `
+fun add(a, b) { return a + b; }
+fun spin() { while (true) {} }
+fun fail() { return nil.x; }
+class Counter {
+  init(n) { this.n = n; }
+  inc(by) { this.n += by; return this.n; }
+}
+var counter = Counter(1);
+`
+
+func newCallLox(t *testing.T, opts ...Option) *Lox {
+	t.Helper()
+	var stderr bytes.Buffer
+	opts = append([]Option{WithStdout(&bytes.Buffer{}), WithStderr(&stderr)}, opts...)
+	l := NewLox(opts...)
+	if err := l.Run(callScript); err != nil {
+		t.Fatalf("Run: %v\n%s", err, stderr.String())
+	}
+	return l
+}
+
+func TestCall(t *testing.T) {
+	l := newCallLox(t)
+
+	t.Run("global", func(t *testing.T) {
+		got, err := l.CallGlobal("add", 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != float64(3) {
+			t.Errorf("add(1, 2) = %v, want 3", got)
+		}
+	})
+
+	t.Run("method", func(t *testing.T) {
+		counter, _ := l.Global("counter")
+		got, err := l.CallMethod(counter, "inc", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != float64(3) {
+			t.Errorf("counter.inc(2) = %v, want 3", got)
+		}
+	})
+
+	t.Run("missing global", func(t *testing.T) {
+		_, err := l.CallGlobal("ad", 1, 2)
+		var rte *interpreter.RunTimeErr
+		if !errors.As(err, &rte) || rte.Code != interpreter.CodeUndefined {
+			t.Fatalf("got %v, want an undefined variable error", err)
+		}
+		if rte.Help == "" {
+			t.Error("expected a suggestion for 'ad'")
+		}
+	})
+
+	t.Run("arity", func(t *testing.T) {
+		_, err := l.CallGlobal("add", 1)
+		var rte *interpreter.RunTimeErr
+		if !errors.As(err, &rte) || rte.Code != interpreter.CodeArity {
+			t.Fatalf("got %v, want an arity error", err)
+		}
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		_, err := l.CallGlobal("fail")
+		var rte *interpreter.RunTimeErr
+		if !errors.As(err, &rte) {
+			t.Fatalf("got %v, want a *RunTimeErr", err)
+		}
+		if rte.Msg != "Only instances have properties" {
+			t.Errorf("got %q", rte.Msg)
+		}
+		// the interpreter can still be called after an error
+		if got, err := l.CallGlobal("add", 2, 2); err != nil || got != float64(4) {
+			t.Errorf("add(2, 2) = %v, %v after an error", got, err)
+		}
+	})
+}
+
+func TestCallContext(t *testing.T) {
+	l := newCallLox(t)
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := l.CallGlobalContext(ctx, "spin")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("got %v, want context.DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		counter, _ := l.Global("counter")
+		_, err := l.CallMethodContext(ctx, counter, "inc", 1)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+		// the context only applies to the call it was given to
+		if _, err := l.CallGlobal("add", 1, 1); err != nil {
+			t.Errorf("add(1, 1) after a cancelled call: %v", err)
+		}
+	})
+
+	t.Run("step limit", func(t *testing.T) {
+		l := newCallLox(t, WithMaxSteps(1000))
+		_, err := l.CallGlobal("spin")
+		if !errors.Is(err, interpreter.ErrStepLimit) {
+			t.Fatalf("got %v, want ErrStepLimit", err)
+		}
+		// every call gets the whole budget
+		for range 3 {
+			if _, err := l.CallGlobal("add", 1, 1); err != nil {
+				t.Fatalf("add(1, 1) after running out of steps: %v", err)
+			}
+		}
+	})
+}
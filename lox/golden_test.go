@@ -0,0 +1,323 @@
+package lox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subarctic2796/gojlox/interpreter"
+)
+
+// the golden tests run every script in tests/ and check what it printed
+// against the annotations in the script:
+//
+//	// expect: <stdout line>
+//	// Error at 'x': <message>          compile error on this line
+//	// [line N] Error at 'x': <message> compile error on line N
+//	// expect runtime error: <message>  runtime error on this line
+//	// expect trace: <traceback line>    a line of the runtime error's traceback
+//	// limits: ArrayElems=5 Instances=1 run the script with these Limits
+//
+// traceback lines are written without the file name and the source lines,
+// so `File "x.lox", line 3, in f` is expected as `line 3, in f`. scripts
+// without any are only checked for the error, not its traceback.
+//
+// scripts with compile errors must exit with 65 and scripts with runtime
+// errors with 70.
+const goldenDir = "../tests"
+
+// skipped holds the tests that don't apply to this dialect of lox, along
+// with why they don't. the keys are path.Match patterns relative to
+// goldenDir, a pattern that matches a directory skips everything in it
+var skipped = map[string]string{
+	"benchmark":   "benchmarks print timings, and are too slow",
+	"scanning":    "only for the scanning chapter, which has its own test mode",
+	"expressions": "only for the expressions chapter, which has its own test mode",
+	"limit":       "clox's compiler limits, and stack_overflow's unused locals are an error",
+
+	"for/statement_*.lox": "`{}` is an empty hashmap, not a block",
+	"*/fun_in_*.lox":      "`fun` starts a lambda in statement position",
+
+	"closure/reuse_closure_slot.lox":      "the local `b` is never read, and unused locals are an error",
+	"closure/unused_closure.lox":          "the local function `foo` is never called, and unused locals are an error",
+	"closure/unused_later_closure.lox":    "the local function `returnB` is never called, and unused locals are an error",
+	"constructor/missing_arguments.lox":   "the parameters of `init` are never read, and unused parameters are an error",
+	"for/scope.lox":                       "the outer `i` in the first block is never read, and unused locals are an error",
+	"function/local_mutual_recursion.lox": "`isEven` can't see the local `isOdd`, so it is never read, and unused locals are an error",
+	"function/missing_arguments.lox":      "the parameters of `f` are never read, and unused parameters are an error",
+	"method/missing_arguments.lox":        "the parameters of `method` are never read, and unused parameters are an error",
+	"regression/40.lox":                   "`capturedVar` and `a` in `callCaller` are never read, and unused locals are an error",
+
+	"variable/collide_with_parameter.lox":   "the redeclared `a` is never read, so unused locals are reported as well",
+	"variable/duplicate_local.lox":          "the redeclared `a` is never read, so unused locals are reported as well",
+	"variable/duplicate_parameter.lox":      "the parameter `arg` is never read, so unused parameters are reported as well",
+	"variable/early_bound.lox":              "the inner `a` is never read, and unused locals are an error",
+	"variable/use_local_in_initializer.lox": "`a` is never read after its initializer, so unused locals are reported as well",
+
+	"operator/*_nonnum*.lox":          "the error is 'Operands must be a number'",
+	"function/body_must_be_block.lox": "function kinds are printed in upper case",
+	"function/print.lox":              "native functions print their name",
+	"method/print_bound_method.lox":   "bound methods print as `<method fn name>`",
+	"number/nan_equality.lox":         "dividing by zero is a runtime error",
+	"string/unterminated.lox":         "lexer errors are lower case, and the parser still reports errors after them",
+}
+
+// skipReason returns why the test or directory at rel is skipped
+func skipReason(rel string) (string, bool) {
+	for pattern, reason := range skipped {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+// scripts that run for longer than this fail, so that a script that
+// loops forever doesn't hang the whole test
+const scriptTimeout = 10 * time.Second
+
+var (
+	expectPat        = regexp.MustCompile(`// expect: ?(.*)`)
+	errorPat         = regexp.MustCompile(`// (Error.*)`)
+	errorLinePat     = regexp.MustCompile(`// \[((java|c) )?line (\d+)\] (Error.*)`)
+	runTimeErrPat    = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectTracePat   = regexp.MustCompile(`// expect trace: (.+)`)
+	nonTestPat       = regexp.MustCompile(`// nontest`)
+	limitsPat        = regexp.MustCompile(`// limits: (.*)`)
+	diagPat          = regexp.MustCompile(`^error(\[\w+\])?: (.*)$`)
+	diagPosPat       = regexp.MustCompile(`^\s*--> .*:(\d+):\d+$`)
+	errorMsgPrefixes = regexp.MustCompile(`^Error( at (end|'.*'))?: `)
+	tracePat         = regexp.MustCompile(`^(Traceback \(most recent call last\):|  .*)$`)
+	traceFramePat    = regexp.MustCompile(`^  (?:File ".*", )?((line \d+, )?in .*|\[Previous line repeated \d+ more times\])$`)
+)
+
+// lineMsg is a message expected on, or reported at, a line of a script
+type lineMsg struct {
+	line int
+	msg  string
+}
+
+func (lm lineMsg) String() string { return fmt.Sprintf("[line %d] %s", lm.line, lm.msg) }
+
+type expectations struct {
+	stdout     []string
+	errs       []lineMsg
+	runTimeErr *lineMsg
+	trace      []string
+	exitCode   int
+	limits     interpreter.Limits
+}
+
+// parseLimits parses the `Name=N` pairs of a limits annotation into limits
+func parseLimits(pairs string, limits *interpreter.Limits) error {
+	fields := map[string]*int{
+		"ArrayElems":  &limits.ArrayElems,
+		"HashEntries": &limits.HashEntries,
+		"StringLen":   &limits.StringLen,
+		"Instances":   &limits.Instances,
+	}
+	for _, pair := range strings.Fields(pairs) {
+		name, val, _ := strings.Cut(pair, "=")
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown limit %q", name)
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("limit %s: %w", name, err)
+		}
+		*field = n
+	}
+	return nil
+}
+
+// normalise strips the parts of an expected error message that this
+// implementation doesn't print, they are shown in the diagnostic instead
+func normalise(msg string) string {
+	msg = errorMsgPrefixes.ReplaceAllString(msg, "")
+	return strings.TrimSuffix(msg, ".")
+}
+
+// parseExpectations returns nil if the script isn't a test
+func parseExpectations(src []byte) (*expectations, error) {
+	exp := &expectations{stdout: make([]string, 0), errs: make([]lineMsg, 0)}
+	scnr := bufio.NewScanner(bytes.NewReader(src))
+	for lineNum := 1; scnr.Scan(); lineNum++ {
+		line := scnr.Text()
+		if nonTestPat.MatchString(line) {
+			return nil, nil
+		}
+		if m := expectPat.FindStringSubmatch(line); m != nil {
+			exp.stdout = append(exp.stdout, m[1])
+		} else if m := errorLinePat.FindStringSubmatch(line); m != nil {
+			// lines for the c implementation only
+			if m[2] == "c" {
+				continue
+			}
+			n, _ := strconv.Atoi(m[3])
+			exp.errs = append(exp.errs, lineMsg{n, normalise(m[4])})
+			exp.exitCode = 65
+		} else if m := errorPat.FindStringSubmatch(line); m != nil {
+			exp.errs = append(exp.errs, lineMsg{lineNum, normalise(m[1])})
+			exp.exitCode = 65
+		} else if m := runTimeErrPat.FindStringSubmatch(line); m != nil {
+			exp.runTimeErr = &lineMsg{lineNum, normalise(m[1])}
+			exp.exitCode = 70
+		} else if m := expectTracePat.FindStringSubmatch(line); m != nil {
+			exp.trace = append(exp.trace, m[1])
+		} else if m := limitsPat.FindStringSubmatch(line); m != nil {
+			if err := parseLimits(m[1], &exp.limits); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(exp.errs) != 0 && exp.runTimeErr != nil {
+		return nil, errors.New("can't expect both compile and runtime errors")
+	}
+	return exp, scnr.Err()
+}
+
+// parseDiags returns the diagnostics in stderr and the frames of their
+// tracebacks, any other lines are returned as unexpected
+func parseDiags(stderr string) ([]lineMsg, []string, []string) {
+	diags, trace, unexpected := make([]lineMsg, 0), make([]string, 0), make([]string, 0)
+	lines := strings.Split(strings.TrimRight(stderr, "\n"), "\n")
+	for idx := 0; idx < len(lines); idx++ {
+		m := diagPat.FindStringSubmatch(lines[idx])
+		if m == nil {
+			if lines[idx] != "" {
+				unexpected = append(unexpected, lines[idx])
+			}
+			continue
+		}
+		lm := lineMsg{0, m[2]}
+		if idx+1 < len(lines) {
+			if pos := diagPosPat.FindStringSubmatch(lines[idx+1]); pos != nil {
+				lm.line, _ = strconv.Atoi(pos[1])
+				idx++
+			}
+		}
+		// skip the source snippet and any notes, keeping the traceback's frames
+		for idx+1 < len(lines) && !diagPat.MatchString(lines[idx+1]) &&
+			(strings.Contains(lines[idx+1], " | ") || strings.HasSuffix(lines[idx+1], " |") ||
+				strings.Contains(lines[idx+1], " = ") || tracePat.MatchString(lines[idx+1])) {
+			idx++
+			if m := traceFramePat.FindStringSubmatch(lines[idx]); m != nil {
+				trace = append(trace, m[1])
+			}
+		}
+		diags = append(diags, lm)
+	}
+	return diags, trace, unexpected
+}
+
+func TestGolden(t *testing.T) {
+	err := filepath.WalkDir(goldenDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(goldenDir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if reason, ok := skipReason(rel); ok {
+			t.Run(rel, func(t *testing.T) { t.Skip(reason) })
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(file) != ".lox" {
+			return nil
+		}
+		t.Run(rel, func(t *testing.T) {
+			t.Parallel()
+			runGolden(t, file)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runGolden(t *testing.T, path string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := parseExpectations(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp == nil {
+		t.Skip("not a test")
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := 0
+	lox := NewLox(
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithExit(func(code int) { exitCode = code }),
+		WithLimits(exp.limits),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+	if err = lox.RunFileContext(ctx, path); errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timed out after %s", scriptTimeout)
+	}
+
+	got := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if stdout.Len() == 0 {
+		got = got[:0]
+	}
+	if !slices.Equal(got, exp.stdout) {
+		t.Errorf("stdout:\ngot:\n\t%s\nwant:\n\t%s",
+			strings.Join(got, "\n\t"), strings.Join(exp.stdout, "\n\t"))
+	}
+
+	diags, trace, unexpected := parseDiags(stderr.String())
+	for _, line := range unexpected {
+		t.Errorf("unexpected output on stderr: %s", line)
+	}
+	if exp.runTimeErr != nil {
+		if len(diags) == 0 {
+			t.Errorf("expected runtime error %s and got none", exp.runTimeErr)
+		} else if diags[0] != *exp.runTimeErr {
+			t.Errorf("expected runtime error %s and got %s", exp.runTimeErr, diags[0])
+		}
+		if len(exp.trace) != 0 && !slices.Equal(trace, exp.trace) {
+			t.Errorf("traceback:\ngot:\n\t%s\nwant:\n\t%s",
+				strings.Join(trace, "\n\t"), strings.Join(exp.trace, "\n\t"))
+		}
+	} else {
+		for _, d := range diags {
+			if !slices.Contains(exp.errs, d) {
+				t.Errorf("unexpected error: %s", d)
+			}
+		}
+		for _, e := range exp.errs {
+			if !slices.Contains(diags, e) {
+				t.Errorf("missing expected error: %s", e)
+			}
+		}
+	}
+
+	if exitCode != exp.exitCode {
+		t.Errorf("exit code: got %d, want %d", exitCode, exp.exitCode)
+	}
+}
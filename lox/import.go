@@ -0,0 +1,104 @@
+package lox
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/lexer"
+	"github.com/Subarctic2796/gojlox/parser"
+	"github.com/Subarctic2796/gojlox/resolver"
+)
+
+// errModule is returned when a module has syntax or resolution errors,
+// they have already been reported and set HadErr, so the script exits with
+// ExitDataErr like it would for the same errors in the script itself
+var errModule = errors.New("the module has errors")
+
+// importer loads modules from fsys, or from the OS's files if it is nil.
+// paths are relative to the file that imports them, and then to each
+// directory in the search path
+type importer struct {
+	l      *Lox
+	fsys   fs.FS
+	search []string
+}
+
+func (im *importer) Resolve(name, from string) (string, error) {
+	candidates := make([]string, 0, len(im.search)+1)
+	if im.fsys == nil {
+		if filepath.IsAbs(name) {
+			return name, im.stat(name)
+		}
+		candidates = append(candidates, filepath.Join(filepath.Dir(from), name))
+		for _, dir := range im.search {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	} else {
+		// paths in a fs.FS are always relative to its root
+		if strings.HasPrefix(name, "/") {
+			name = strings.TrimLeft(name, "/")
+			return name, im.stat(name)
+		}
+		candidates = append(candidates, path.Join(path.Dir(from), name))
+		for _, dir := range im.search {
+			candidates = append(candidates, path.Join(dir, name))
+		}
+	}
+	for _, cand := range candidates {
+		err := im.stat(cand)
+		if err == nil {
+			return cand, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", fs.ErrNotExist
+}
+
+func (im *importer) stat(name string) error {
+	var err error
+	if im.fsys == nil {
+		_, err = os.Stat(name)
+	} else if !fs.ValidPath(name) {
+		err = fs.ErrNotExist
+	} else {
+		_, err = fs.Stat(im.fsys, name)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return fs.ErrNotExist
+	}
+	return err
+}
+
+func (im *importer) Load(key string) ([]ast.Stmt, error) {
+	var src []byte
+	var err error
+	if im.fsys == nil {
+		src, err = os.ReadFile(key)
+	} else {
+		src, err = fs.ReadFile(im.fsys, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lex := scanner.NewLexer("", im.l.diags)
+	lex.Name = key
+	lex.Reset(string(src))
+	toks, lexErr := lex.ScanTokens()
+	stmts, err := parser.NewParser(toks, im.l.diags).Parse()
+	if errors.Join(lexErr, err) != nil {
+		im.l.HadErr = true
+		return nil, errModule
+	}
+	if err = resolver.NewResolver(im.l.interpreter, im.l.diags).ResolveStmts(stmts); err != nil {
+		im.l.HadErr = true
+		return nil, errModule
+	}
+	return stmts, nil
+}
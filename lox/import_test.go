@@ -0,0 +1,55 @@
+package lox
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestImportFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.lox":           {Data: []byte(`import "app/greet.lox" as greet; print greet.hello("fs");`)},
+		"app/greet.lox":      {Data: []byte(`from "names.lox" import join; fun hello(name) { return join("hello", name); }`)},
+		"app/names.lox":      {Data: []byte(`fun join(a, b) { return a + " " + b; }`)},
+		"lib/strings.lox":    {Data: []byte(`var name = "strings";`)},
+		"vendor/strings.lox": {Data: []byte(`var name = "vendored";`)},
+	}
+	tests := []struct {
+		name, src, want string
+	}{
+		{"relative", `import "app/greet.lox" as greet; print greet.hello("fs");`, "hello fs\n"},
+		{"rooted", `import "/app/names.lox" as names; print names.join("a", "b");`, "a b\n"},
+		{"search path", `import "strings.lox" as s; print s.name;`, "strings\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			l := NewLox(WithStdout(&stdout), WithStderr(&stderr), WithFS(fsys), WithSearchPath("lib", "vendor"))
+			if err := l.Run(tt.src); err != nil {
+				t.Fatalf("Run: %v\n%s", err, stderr.String())
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("run file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		l := NewLox(WithStdout(&stdout), WithStderr(&stderr), WithFS(fsys))
+		if err := l.RunFile("main.lox"); err != nil {
+			t.Fatalf("RunFile: %v\n%s", err, stderr.String())
+		}
+		if got, want := stdout.String(), "hello fs\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var stderr bytes.Buffer
+		l := NewLox(WithStdout(&bytes.Buffer{}), WithStderr(&stderr), WithFS(fsys))
+		if err := l.Run(`import "../main.lox" as m;`); err == nil {
+			t.Fatal("expected an error importing a path outside of the fs")
+		}
+	})
+}
@@ -2,83 +2,228 @@ package lox
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"text/tabwriter"
 
+	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/interpreter"
 	"github.com/Subarctic2796/gojlox/lexer"
 	"github.com/Subarctic2796/gojlox/parser"
 	"github.com/Subarctic2796/gojlox/resolver"
+	"github.com/Subarctic2796/gojlox/token"
 )
 
 type Lox struct {
 	HadErr        bool
 	HadRunTimeErr bool
-	interpreter   *interpreter.Interpreter
-	resolver      *resolver.Resolver
-	parser        *parser.Parser
-	lexer         *scanner.Lexer
+	// when either is set, Run writes the tokens and/or ast to stdout
+	// and returns without resolving or interpreting them
+	DumpTokens, DumpAST bool
+	interpreter         *interpreter.Interpreter
+	resolver            *resolver.Resolver
+	parser              *parser.Parser
+	lexer               *scanner.Lexer
+	diags               *diag.Reporter
+	stdout, stderr      io.Writer
+	stdin               io.Reader
+	exit                func(code int)
+	maxSteps, maxDepth  int
+	limits              interpreter.Limits
+	// where imports are loaded from, nil for the OS's files
+	fsys fs.FS
+	// directories searched for imports that aren't next to the importing file
+	searchPath []string
 }
 
-func NewLox() *Lox {
-	l := Lox{
-		false,
-		false,
-		interpreter.NewInterpreter(),
-		nil,
-		parser.NewParser(nil),
-		scanner.NewLexer(""),
+// exit codes for scripts that failed, from sysexits.h
+const (
+	ExitDataErr  = 65 // the script has syntax or resolution errors
+	ExitSoftware = 70 // the script had a runtime error
+)
+
+// NewLox creates a Lox that reads and writes to os.Stdin, os.Stdout and
+// os.Stderr, which can be changed with opts
+func NewLox(opts ...Option) *Lox {
+	l := &Lox{stdout: os.Stdout, stderr: os.Stderr, stdin: os.Stdin}
+	for _, opt := range opts {
+		opt(l)
 	}
-	l.resolver = resolver.NewResolver(l.interpreter)
-	return &l
+	l.diags = diag.NewReporter(l.stderr)
+	l.interpreter = interpreter.NewInterpreter(l.diags)
+	l.interpreter.Stdout = l.stdout
+	l.interpreter.MaxSteps = l.maxSteps
+	l.interpreter.Limits = l.limits
+	if l.maxDepth != 0 {
+		l.interpreter.MaxDepth = l.maxDepth
+	}
+	l.interpreter.Importer = &importer{l, l.fsys, l.searchPath}
+	l.resolver = resolver.NewResolver(l.interpreter, l.diags)
+	l.parser = parser.NewParser(nil, l.diags)
+	l.lexer = scanner.NewLexer("", l.diags)
+	return l
+}
+
+// RegisterFunc defines a global native function, see Interpreter.RegisterFunc
+func (l *Lox) RegisterFunc(name string, arity int, fn interpreter.GoFunc) {
+	l.interpreter.RegisterFunc(name, arity, fn)
+}
+
+// RegisterModule defines a global module, see Interpreter.RegisterModule
+func (l *Lox) RegisterModule(name string, members map[string]any) *interpreter.LoxModule {
+	return l.interpreter.RegisterModule(name, members)
+}
+
+// Bind defines a global from a Go value, see Interpreter.Bind
+func (l *Lox) Bind(name string, v any) error {
+	return l.interpreter.Bind(name, v)
+}
+
+// Global returns the value of a global variable, see Interpreter.Global
+func (l *Lox) Global(name string) (any, bool) {
+	return l.interpreter.Global(name)
+}
+
+// Call calls a lox function or class from Go, see Interpreter.Call
+func (l *Lox) Call(callee any, args ...any) (any, error) {
+	return l.interpreter.Call(callee, args...)
+}
+
+// CallGlobal calls a global lox function or class from Go,
+// see Interpreter.CallGlobal
+func (l *Lox) CallGlobal(name string, args ...any) (any, error) {
+	return l.interpreter.CallGlobal(name, args...)
+}
+
+// CallMethod calls a method of a lox instance from Go,
+// see Interpreter.CallMethod
+func (l *Lox) CallMethod(obj any, name string, args ...any) (any, error) {
+	return l.interpreter.CallMethod(obj, name, args...)
+}
+
+// CallContext is Call with a context, see Interpreter.CallContext
+func (l *Lox) CallContext(ctx context.Context, callee any, args ...any) (any, error) {
+	return l.interpreter.CallContext(ctx, callee, args...)
+}
+
+// CallGlobalContext is CallGlobal with a context,
+// see Interpreter.CallContext
+func (l *Lox) CallGlobalContext(ctx context.Context, name string, args ...any) (any, error) {
+	return l.interpreter.CallGlobalContext(ctx, name, args...)
+}
+
+// CallMethodContext is CallMethod with a context,
+// see Interpreter.CallContext
+func (l *Lox) CallMethodContext(ctx context.Context, obj any, name string, args ...any) (any, error) {
+	return l.interpreter.CallMethodContext(ctx, obj, name, args...)
+}
+
+// FromLox converts a lox value to a Go value, see Interpreter.FromLox
+func (l *Lox) FromLox(val any, ptr any) error {
+	return l.interpreter.FromLox(val, ptr)
+}
+
+// ExitCode returns the exit code for the last script that was run,
+// 0 if it didn't fail
+func (l *Lox) ExitCode() int {
+	if l.HadErr {
+		return ExitDataErr
+	}
+	if l.HadRunTimeErr {
+		return ExitSoftware
+	}
+	return 0
 }
 
 func (l *Lox) RunFile(path string) error {
-	f, err := os.ReadFile(path)
+	return l.RunFileContext(context.Background(), path)
+}
+
+// RunFileContext runs the script at path until it finishes or ctx is done
+func (l *Lox) RunFileContext(ctx context.Context, path string) error {
+	var f []byte
+	var err error
+	if l.fsys != nil {
+		f, err = fs.ReadFile(l.fsys, path)
+	} else {
+		f, err = os.ReadFile(path)
+	}
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(l.stderr, err)
 		return err
 	}
-	err = l.Run(string(f))
-	if err != nil {
-		if l.HadErr {
-			os.Exit(65)
-		}
-		if l.HadRunTimeErr {
-			os.Exit(70)
+	l.lexer.Name = path
+	err = l.run(ctx, string(f), path)
+	if err != nil && l.exit != nil {
+		if code := l.ExitCode(); code != 0 {
+			l.exit(code)
 		}
-		panic(err)
 	}
-	return nil
+	return err
 }
 
 func (l *Lox) RunPrompt() error {
-	scnr := bufio.NewScanner(os.Stdin)
+	scnr := bufio.NewScanner(l.stdin)
+	l.lexer.Name = ""
 	for {
-		fmt.Print("> ")
+		fmt.Fprint(l.stdout, "> ")
 		if !scnr.Scan() {
-			fmt.Print("\n")
+			fmt.Fprint(l.stdout, "\n")
 			return scnr.Err()
 		}
 		_ = l.Run(scnr.Text())
-		l.HadErr, l.HadRunTimeErr = false, false
 	}
 }
 
 func (l *Lox) Run(src string) error {
+	return l.RunContext(context.Background(), src)
+}
+
+// RunContext runs src until it finishes or ctx is done, in which case the
+// returned error wraps ctx.Err()
+func (l *Lox) RunContext(ctx context.Context, src string) error {
+	return l.run(ctx, src, "")
+}
+
+// run runs src, which was read from the file at path if path isn't ""
+func (l *Lox) run(ctx context.Context, src, path string) error {
+	// ExitCode is only for the last script
+	l.HadErr, l.HadRunTimeErr = false, false
+	l.diags.Reset()
 	l.lexer.Reset(src)
-	toks, err := l.lexer.ScanTokens()
-	if err != nil {
-		l.HadErr = true
-		return err
+	// the tokens are still parsed if there were errors
+	// so that all the syntax errors are reported together
+	toks, lexErr := l.lexer.ScanTokens()
+	if l.DumpTokens {
+		dumpTokens(l.stdout, toks)
+		if !l.DumpAST {
+			if lexErr != nil {
+				l.HadErr = true
+			}
+			return lexErr
+		}
 	}
 
 	l.parser.Reset(toks)
 	stmts, err := l.parser.Parse()
-	if err != nil {
+	if l.DumpAST {
+		// syntax errors still leave a partial ast that is worth showing
+		if dumpErr := ast.Dump(l.stdout, stmts); dumpErr != nil {
+			return dumpErr
+		}
+	}
+	if err = errors.Join(lexErr, err); err != nil {
 		l.HadErr = true
 		return err
 	}
+	if l.DumpAST {
+		return nil
+	}
 
 	l.resolver.Reset()
 	err = l.resolver.ResolveStmts(stmts)
@@ -87,7 +232,7 @@ func (l *Lox) Run(src string) error {
 		return err
 	}
 
-	err = l.interpreter.Interpret(stmts)
+	err = l.interpret(ctx, stmts, path)
 	if err != nil {
 		l.HadRunTimeErr = true
 		return err
@@ -95,3 +240,26 @@ func (l *Lox) Run(src string) error {
 
 	return nil
 }
+
+// interpret runs stmts, as the module at path if they were read from a file
+func (l *Lox) interpret(ctx context.Context, stmts []ast.Stmt, path string) error {
+	if path != "" {
+		if key, err := l.interpreter.Importer.Resolve(path, ""); err == nil {
+			return l.interpreter.InterpretFileContext(ctx, key, stmts)
+		}
+	}
+	return l.interpreter.InterpretContext(ctx, stmts)
+}
+
+func dumpTokens(w io.Writer, toks []token.Token) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "POS\tKIND\tLEXEME\tLITERAL")
+	for _, tok := range toks {
+		lit := ""
+		if tok.Literal != nil {
+			lit = fmt.Sprintf("%#v", tok.Literal)
+		}
+		fmt.Fprintf(tw, "%d:%d\t%s\t%s\t%s\n", tok.Line, tok.Col, tok.Kind, tok.Lexeme, lit)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,162 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Subarctic2796/gojlox/interpreter"
+)
+
+func TestExitCodeIsForLastRun(t *testing.T) {
+	l := NewLox(WithStdout(&bytes.Buffer{}), WithStderr(&bytes.Buffer{}))
+	runs := []struct {
+		src  string
+		want int
+	}{
+		{"print nil.x;", ExitSoftware},
+		{"print 1;", 0},
+		{"print (;", ExitDataErr},
+		{"print 1;", 0},
+	}
+	for _, run := range runs {
+		_ = l.Run(run.src)
+		if got := l.ExitCode(); got != run.want {
+			t.Errorf("%q: ExitCode() = %d, want %d", run.src, got, run.want)
+		}
+	}
+}
+
+func TestLimitsArePerRun(t *testing.T) {
+	l := NewLox(
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithLimits(interpreter.Limits{ArrayElems: 5}),
+	)
+	for run := range 3 {
+		if err := l.Run("var a = [1, 2, 3];"); err != nil {
+			t.Fatalf("run %d: %v", run+1, err)
+		}
+	}
+	err := l.Run("var a = [1, 2, 3]; var b = [4, 5, 6];")
+	if !errors.Is(err, interpreter.ErrMemLimit) {
+		t.Fatalf("got %v, want ErrMemLimit", err)
+	}
+	// the run that failed doesn't count against the next one either
+	if err := l.Run("var c = [1, 2, 3, 4, 5];"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	const spin = "var n = 0; while (true) { n = n + 1; }"
+	tests := []struct {
+		name string
+		opts []Option
+		ctx  func() (context.Context, context.CancelFunc)
+		want error
+	}{
+		{
+			name: "cancelled while running",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+		{
+			name: "cancelled before running",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+		{
+			name: "deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+		{
+			name: "step limit",
+			opts: []Option{WithMaxSteps(1000)},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			want: interpreter.ErrStepLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			opts := append([]Option{WithStdout(&stdout), WithStderr(&bytes.Buffer{})}, tt.opts...)
+			l := NewLox(opts...)
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			err := l.RunContext(ctx, spin)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if got := l.ExitCode(); got != ExitSoftware {
+				t.Errorf("ExitCode() = %d, want %d", got, ExitSoftware)
+			}
+			// the interpreter is still usable, and the next run gets a
+			// fresh context and step budget
+			if err := l.Run("var m = 0; for (var i = 0; i < 100; i += 1) m += i; print m;"); err != nil {
+				t.Fatalf("next run: %v", err)
+			}
+			if got, want := stdout.String(), "4950\n"; got != want {
+				t.Errorf("next run printed %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLimitsCountGoValues(t *testing.T) {
+	tests := []struct {
+		name, src string
+		limits    interpreter.Limits
+	}{
+		{"slice", "var a = nums(3); var b = nums(3);", interpreter.Limits{ArrayElems: 5}},
+		{"nested slices", "var a = grid(2, 2);", interpreter.Limits{ArrayElems: 5}},
+		{"many results", "var a = nums(3); var b = pair();", interpreter.Limits{ArrayElems: 4}},
+		{"map", "var a = names(2); var b = names(2);", interpreter.Limits{HashEntries: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLox(WithStdout(&bytes.Buffer{}), WithStderr(&bytes.Buffer{}), WithLimits(tt.limits))
+			binds := map[string]any{
+				"nums": func(n int) []int { return make([]int, n) },
+				"grid": func(w, h int) [][]int {
+					rows := make([][]int, h)
+					for i := range rows {
+						rows[i] = make([]int, w)
+					}
+					return rows
+				},
+				"pair": func() (int, int) { return 1, 2 },
+				"names": func(n int) map[string]int {
+					m := make(map[string]int, n)
+					for i := range n {
+						m[string(rune('a'+i))] = i
+					}
+					return m
+				},
+			}
+			for name, v := range binds {
+				if err := l.Bind(name, v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := l.Run(tt.src); !errors.Is(err, interpreter.ErrMemLimit) {
+				t.Fatalf("got %v, want ErrMemLimit", err)
+			}
+		})
+	}
+}
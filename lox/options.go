@@ -0,0 +1,67 @@
+package lox
+
+import (
+	"io"
+	"io/fs"
+
+	"github.com/Subarctic2796/gojlox/interpreter"
+)
+
+// Option configures a Lox created by NewLox
+type Option func(*Lox)
+
+// WithStdout sets where `print` and `printf` write to, and where the
+// `--tokens` and `--ast` dumps go. it defaults to os.Stdout
+func WithStdout(w io.Writer) Option {
+	return func(l *Lox) { l.stdout = w }
+}
+
+// WithStderr sets where errors are reported to. it defaults to os.Stderr
+func WithStderr(w io.Writer) Option {
+	return func(l *Lox) { l.stderr = w }
+}
+
+// WithStdin sets where RunPrompt reads lines from. it defaults to os.Stdin
+func WithStdin(r io.Reader) Option {
+	return func(l *Lox) { l.stdin = r }
+}
+
+// WithMaxSteps limits how many steps, loop iterations and calls, each run
+// of a script can take. scripts that take more fail with an error that wraps
+// interpreter.ErrStepLimit. 0, the default, means there is no limit
+func WithMaxSteps(n int) Option {
+	return func(l *Lox) { l.maxSteps = n }
+}
+
+// WithMaxDepth limits how deep calls can be nested, calls past it fail with
+// a "Stack overflow" runtime error. it defaults to interpreter.DefaultMaxDepth
+// and a negative n means there is no limit
+func WithMaxDepth(n int) Option {
+	return func(l *Lox) { l.maxDepth = n }
+}
+
+// WithLimits caps how much memory scripts can allocate, going over a limit
+// is a runtime error. there are no limits by default
+func WithLimits(limits interpreter.Limits) Option {
+	return func(l *Lox) { l.limits = limits }
+}
+
+// WithFS loads RunFile's scripts and imported modules from fsys instead of
+// the OS's files, like an embed.FS of scripts that are shipped with the host.
+// a script run with Run imports relative to the root of fsys
+func WithFS(fsys fs.FS) Option {
+	return func(l *Lox) { l.fsys = fsys }
+}
+
+// WithSearchPath adds directories that imports are looked for in when
+// they aren't found next to the file that imports them, in order
+func WithSearchPath(dirs ...string) Option {
+	return func(l *Lox) { l.searchPath = append(l.searchPath, dirs...) }
+}
+
+// WithExit sets the function RunFile calls with the exit code of a script
+// that failed, see ExitCode. by default RunFile only returns the error,
+// so that nothing can end the host process
+func WithExit(exit func(code int)) Option {
+	return func(l *Lox) { l.exit = exit }
+}
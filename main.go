@@ -1,22 +1,41 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/Subarctic2796/gojlox/lox"
 )
 
 func main() {
-	os.Args = os.Args[1:]
-	lox := lox.NewLox()
-	switch len(os.Args) {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(runFmt(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	dumpTokens := flag.Bool("tokens", false, "print the scanned tokens and exit without interpreting")
+	dumpAST := flag.Bool("ast", false, "print the parsed ast and exit without interpreting")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: gojlox [--tokens] [--ast] [script]")
+		fmt.Fprintln(os.Stderr, "       gojlox fmt [-w] [-d] [path ...]")
+		fmt.Fprintln(os.Stderr, "imports that aren't next to the importing file are looked for in $LOXPATH")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	lox := lox.NewLox(
+		lox.WithExit(os.Exit),
+		lox.WithSearchPath(filepath.SplitList(os.Getenv("LOXPATH"))...),
+	)
+	lox.DumpTokens, lox.DumpAST = *dumpTokens, *dumpAST
+	switch flag.NArg() {
 	case 0:
 		lox.RunPrompt()
 	case 1:
-		lox.RunFile(os.Args[0])
+		lox.RunFile(flag.Arg(0))
 	default:
-		fmt.Fprintln(os.Stderr, "Usage: gojlox [script]")
+		flag.Usage()
 		os.Exit(64)
 	}
 }
@@ -1,11 +1,13 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"slices"
+	"strings"
 
 	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/token"
 )
 
@@ -28,8 +30,54 @@ const (
 	// AlreadyInScope       = "Already a variable with this name in this scope"
 	// LocalInitializesSelf = "Can't read local variable in its own initializer"
 	// LocalNotRead         = "Local variable is not used"
+
+	invalidTarget = "Invalid assignment target"
+	setSlice      = "Can't use slicing to set values"
+	tooManyParams = "Can't have more than 255 parameters"
+	tooManyArgs   = "Can't have more than 255 arguments"
+	breakNotLoop  = "Must be in a loop or switch to use 'break'"
+	contNotLoop   = "Must be in a loop to use 'continue'"
+
+	labelNotLoop   = "Expect a loop after a label"
+	labelInUse     = "Already a loop with this label"
+	labelUndefined = "No loop with this label"
+
+	manyDefaults = "Can only have one default case"
 )
 
+const (
+	codeSyntax  = "P0001"
+	codeContext = "P0002"
+	codeTarget  = "P0003"
+	codeLimit   = "P0004"
+	codeHash    = "P0005"
+)
+
+// errCodes maps the messages of errors that aren't plain syntax errors to their code
+var errCodes = map[string]string{
+	returnTopLevel:     codeContext,
+	returnFromInit:     codeContext,
+	inheritsSelf:       codeContext,
+	thisNotInClass:     codeContext,
+	thisInStatic:       codeContext,
+	initIsStatic:       codeContext,
+	staticNotInClass:   codeContext,
+	staticNeedsMethod:  codeContext,
+	superInStatic:      codeContext,
+	superNotInClass:    codeContext,
+	superNotInSubClass: codeContext,
+	breakNotLoop:       codeContext,
+	contNotLoop:        codeContext,
+	labelInUse:         codeContext,
+	labelUndefined:     codeContext,
+	manyDefaults:       codeContext,
+	invalidTarget:      codeTarget,
+	setSlice:           codeTarget,
+	tooManyParams:      codeLimit,
+	tooManyArgs:        codeLimit,
+	UnHashable:         codeHash,
+}
+
 type clsType byte
 
 const (
@@ -38,75 +86,78 @@ const (
 	cls_SUBCLASS
 )
 
-// TODO: even if we error we should still return the AST
 type Parser struct {
 	tokens         []token.Token
 	cur, loopDepth int
-	curClass       clsType
-	curFN          ast.FnType
-	curErr         error
+	// how many blocks the parser is inside of
+	blockDepth int
+	curClass   clsType
+	curFN      ast.FnType
+	// labels of the loops the parser is inside of, innermost last
+	labels []*token.Token
+	// label of the loop that is about to be parsed
+	nextLabel *token.Token
+	// how many switches the parser is inside of, `break` can leave them
+	switchDepth int
+	// every error reported since the last Reset
+	errs  []error
+	diags *diag.Reporter
 }
 
-func NewParser(tokens []token.Token) *Parser {
-	return &Parser{tokens, 0, 0, cls_NONE, ast.FN_NONE, nil}
+func NewParser(tokens []token.Token, diags *diag.Reporter) *Parser {
+	return &Parser{tokens, 0, 0, 0, cls_NONE, ast.FN_NONE, nil, nil, 0, make([]error, 0), diags}
 }
 
 func (p *Parser) Reset(tokens []token.Token) {
 	p.tokens = tokens
-	p.cur, p.loopDepth = 0, 0
+	p.cur, p.loopDepth, p.blockDepth, p.switchDepth = 0, 0, 0, 0
 	p.curClass = cls_NONE
 	p.curFN = ast.FN_NONE
-	p.curErr = nil
+	p.labels, p.nextLabel = p.labels[:0], nil
+	p.errs = p.errs[:0]
 }
 
+// Parse parses every statement, recovering from syntax errors so that they
+// can all be reported in one go. Statements that failed to parse are
+// replaced with *ast.BadStmt, so the returned ast is always usable by tools,
+// but it should only be run if the returned error is nil.
+// The error joins every error that was reported
 func (p *Parser) Parse() ([]ast.Stmt, error) {
 	stmts := make([]ast.Stmt, 0, 16)
 	for !p.isAtEnd() {
 		// don't need to check the error
-		// as parseErr reports it and sets curErr
+		// as parseErr reports it and records it in errs
 		stmt, _ := p.declaration()
 		stmts = append(stmts, stmt)
 	}
-	// don't need to check if there was an error as the caller will check
-	return stmts, p.curErr
+	return stmts, errors.Join(p.errs...)
 }
 
+// declaration always returns a statement, if it failed to parse then the
+// statement is an *ast.BadStmt and the parser has synchronised
 func (p *Parser) declaration() (ast.Stmt, error) {
+	from := p.peek()
+	stmt, err := p.declarationOrErr()
+	if err != nil {
+		p.synchronise()
+		return &ast.BadStmt{From: from, To: p.previous()}, err
+	}
+	return stmt, nil
+}
+
+func (p *Parser) declarationOrErr() (ast.Stmt, error) {
 	if p.match(token.CLASS) {
-		val, err := p.classDeclaration()
-		if err != nil {
-			p.synchronise()
-			return nil, err
-		}
-		return val, nil
+		return p.classDeclaration()
 	}
 	// check for lambdas
 	if p.check(token.FUN) && p.checkNext(token.IDENTIFIER) {
-		_, err := p.consume(token.FUN, "")
-		if err != nil {
-			return nil, err
-		}
-		val, err := p.function(ast.FN_FUNC)
-		if err != nil {
-			p.synchronise()
-			return nil, err
-		}
-		return val, nil
+		p.advance()
+		return p.function(ast.FN_FUNC)
 	}
 	if p.match(token.VAR) {
-		val, err := p.varDeclaration()
-		if err != nil {
-			p.synchronise()
-			return nil, err
-		}
-		return val, nil
+		return p.varDeclaration()
 	}
-	val, err := p.statement()
-	if err != nil {
-		p.synchronise()
-		return nil, err
-	}
-	return val, err
+	return p.statement()
 }
 
 func (p *Parser) classDeclaration() (ast.Stmt, error) {
@@ -143,7 +194,9 @@ func (p *Parser) classDeclaration() (ast.Stmt, error) {
 		}
 		method, err := p.function(kind)
 		if err != nil {
-			return nil, err
+			// the error has been reported, carry on with the next method
+			p.synchroniseMethod()
+			continue
 		}
 		methods = append(methods, method)
 	}
@@ -160,7 +213,8 @@ func (p *Parser) function(kind ast.FnType) (*ast.Function, error) {
 	if err != nil {
 		return nil, err
 	}
-	if name.Lexeme == "init" {
+	// functions called init are only initializers if they are methods
+	if name.Lexeme == "init" && kind != ast.FN_FUNC {
 		if kind == ast.FN_STATIC {
 			// only report error, this way we don't mess up the state of the parser
 			// it also makes parser errors much less noisy
@@ -182,9 +236,12 @@ func (p *Parser) function(kind ast.FnType) (*ast.Function, error) {
 }
 
 func (p *Parser) lambda(kind ast.FnType) (*ast.Lambda, error) {
-	prvFn := p.curFN
-	p.curFN = kind
-	defer func() { p.curFN = prvFn }()
+	prvFn, prvLoopDepth, prvSwitchDepth, prvLabels := p.curFN, p.loopDepth, p.switchDepth, p.labels
+	// `break` and `continue` can't leave a function
+	p.curFN, p.loopDepth, p.switchDepth, p.labels = kind, 0, 0, nil
+	defer func() {
+		p.curFN, p.loopDepth, p.switchDepth, p.labels = prvFn, prvLoopDepth, prvSwitchDepth, prvLabels
+	}()
 	msg := fmt.Sprintf("Expect '(' after %s name", kind)
 	fnKeyword := p.previous()
 	_, err := p.consume(token.LPAREN, msg)
@@ -198,7 +255,7 @@ func (p *Parser) lambda(kind ast.FnType) (*ast.Lambda, error) {
 			if len(params) >= 255 {
 				// only report error, this way we don't mess up the state of the parser
 				// it also makes parser errors much less noisy
-				_ = p.parseErr(p.peek(), "Can't have more than 255 parameters")
+				_ = p.parseErr(p.peek(), tooManyParams)
 			}
 			ident, err := p.consume(token.IDENTIFIER, "Expect parameter name")
 			if err != nil {
@@ -247,8 +304,10 @@ func (p *Parser) varDeclaration() (ast.Stmt, error) {
 }
 
 func (p *Parser) statement() (ast.Stmt, error) {
-	if p.match(token.BREAK) {
-		return p.breakStatement()
+	if p.match(token.BREAK, token.CONTINUE) {
+		return p.branchStatement()
+	} else if p.check(token.IDENTIFIER) && p.checkNext(token.COLON) {
+		return p.labelledStatement()
 	} else if p.match(token.FOR) {
 		return p.forStatement()
 	} else if p.match(token.IF) {
@@ -257,8 +316,16 @@ func (p *Parser) statement() (ast.Stmt, error) {
 		return p.printStatement()
 	} else if p.match(token.RETURN) {
 		return p.returnStatement()
+	} else if p.match(token.THROW) {
+		return p.throwStatement()
+	} else if p.match(token.TRY) {
+		return p.tryStatement()
+	} else if p.match(token.IMPORT, token.FROM) {
+		return p.importStatement()
 	} else if p.match(token.WHILE) {
 		return p.whileStatement()
+	} else if p.match(token.SWITCH) {
+		return p.switchStatement()
 	} else if p.match(token.LBRACE) {
 		block, err := p.block()
 		if err != nil {
@@ -269,18 +336,77 @@ func (p *Parser) statement() (ast.Stmt, error) {
 	return p.expressionStatement()
 }
 
-func (p *Parser) breakStatement() (ast.Stmt, error) {
-	if p.loopDepth == 0 {
-		// only report error, this way we don't mess up the state of the parser
-		// it also makes parser errors much less noisy
-		_ = p.parseErr(p.previous(), "Must be in a loop to use 'break'")
-	}
+// branchStatement parses `break` and `continue`
+func (p *Parser) branchStatement() (ast.Stmt, error) {
 	keyword := p.previous()
-	_, err := p.consume(token.SEMICOLON, "Expect ';' after 'break'")
+	// only report errors, this way we don't mess up the state of the parser
+	// it also makes parser errors much less noisy
+	if keyword.Kind == token.BREAK && p.loopDepth == 0 && p.switchDepth == 0 {
+		_ = p.parseErr(keyword, breakNotLoop)
+	} else if keyword.Kind == token.CONTINUE && p.loopDepth == 0 {
+		_ = p.parseErr(keyword, contNotLoop)
+	}
+	var label *token.Token
+	if p.match(token.IDENTIFIER) {
+		label = p.previous()
+		if p.findLabel(label) == nil {
+			_ = p.parseErr(label, labelUndefined)
+		}
+	}
+	_, err := p.consume(token.SEMICOLON, fmt.Sprintf("Expect ';' after '%s'", keyword.Lexeme))
 	if err != nil {
 		return nil, err
 	}
-	return &ast.Control{Keyword: keyword, Value: nil}, nil
+	return &ast.Control{Keyword: keyword, Value: nil, Label: label}, nil
+}
+
+// labelledStatement parses `name: ` followed by a loop
+func (p *Parser) labelledStatement() (ast.Stmt, error) {
+	label := p.advance()
+	p.advance() // :
+	if p.findLabel(label) != nil {
+		// only report error, this way we don't mess up the state of the parser
+		// it also makes parser errors much less noisy
+		_ = p.parseErr(label, labelInUse)
+	}
+	if !p.check(token.WHILE) && !p.check(token.FOR) {
+		return nil, p.parseErr(p.peek(), labelNotLoop)
+	}
+	p.nextLabel = label
+	return p.statement()
+}
+
+// findLabel returns the label of the loop called name that the parser is
+// inside of, or nil if there isn't one
+func (p *Parser) findLabel(name *token.Token) *token.Token {
+	for _, label := range p.labels {
+		if label.Lexeme == name.Lexeme {
+			return label
+		}
+	}
+	return nil
+}
+
+// loopLabel returns the label of the loop being parsed, it must be called
+// before anything else in the loop is parsed
+func (p *Parser) loopLabel() *token.Token {
+	label := p.nextLabel
+	p.nextLabel = nil
+	return label
+}
+
+// enterLoop is called before parsing the body of a loop with label,
+// the returned func must be called after the body
+func (p *Parser) enterLoop(label *token.Token) func() {
+	prvLabels := p.labels
+	if label != nil {
+		p.labels = append(p.labels, label)
+	}
+	p.loopDepth++
+	return func() {
+		p.loopDepth--
+		p.labels = prvLabels
+	}
 }
 
 func (p *Parser) returnStatement() (ast.Stmt, error) {
@@ -309,11 +435,192 @@ func (p *Parser) returnStatement() (ast.Stmt, error) {
 	return &ast.Control{Keyword: keyword, Value: val}, nil
 }
 
+func (p *Parser) throwStatement() (ast.Stmt, error) {
+	keyword := p.previous()
+	val, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after thrown value")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.Control{Keyword: keyword, Value: val}, nil
+}
+
+func (p *Parser) importStatement() (ast.Stmt, error) {
+	stmt := &ast.Import{Keyword: p.previous()}
+	var err error
+	stmt.Path, err = p.consume(token.STRING, fmt.Sprintf("Expect module path after '%s'", stmt.Keyword.Lexeme))
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Keyword.Kind == token.IMPORT {
+		_, err = p.consume(token.AS, "Expect 'as' after module path")
+		if err != nil {
+			return nil, err
+		}
+		stmt.Name, err = p.consume(token.IDENTIFIER, "Expect module name after 'as'")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, err = p.consume(token.IMPORT, "Expect 'import' after module path")
+		if err != nil {
+			return nil, err
+		}
+		for {
+			name, err := p.consume(token.IDENTIFIER, "Expect name to import")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Names = append(stmt.Names, name)
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after import")
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func (p *Parser) tryStatement() (ast.Stmt, error) {
+	stmt := &ast.Try{Keyword: p.previous()}
+	_, err := p.consume(token.LBRACE, "Expect '{' after 'try'")
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Body, err = p.block(); err != nil {
+		return nil, err
+	}
+	if p.match(token.CATCH) {
+		// the name is optional, as unused variables are an error
+		if p.match(token.LPAREN) {
+			stmt.CatchName, err = p.consume(token.IDENTIFIER, "Expect error name after '('")
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.consume(token.RPAREN, "Expect ')' after error name")
+			if err != nil {
+				return nil, err
+			}
+		}
+		_, err = p.consume(token.LBRACE, "Expect '{' after 'catch'")
+		if err != nil {
+			return nil, err
+		}
+		if stmt.Catch, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	if p.match(token.FINALLY) {
+		_, err = p.consume(token.LBRACE, "Expect '{' after 'finally'")
+		if err != nil {
+			return nil, err
+		}
+		if stmt.Finally, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	if stmt.Catch == nil && stmt.Finally == nil {
+		return nil, p.parseErr(p.peek(), "Expect 'catch' or 'finally' after try block")
+	}
+	return stmt, nil
+}
+
+func (p *Parser) switchStatement() (ast.Stmt, error) {
+	stmt := &ast.Switch{Keyword: p.previous(), Cases: make([]ast.SwitchCase, 0)}
+	_, err := p.consume(token.LPAREN, "Expect '(' after 'switch'")
+	if err != nil {
+		return nil, err
+	}
+	stmt.Value, err = p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(token.RPAREN, "Expect ')' after switch value")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(token.LBRACE, "Expect '{' before switch body")
+	if err != nil {
+		return nil, err
+	}
+
+	p.blockDepth++
+	p.switchDepth++
+	defer func() {
+		p.blockDepth--
+		p.switchDepth--
+	}()
+	for !p.check(token.RBRACE) && !p.isAtEnd() {
+		if p.match(token.DEFAULT) {
+			if stmt.Default != nil {
+				// only report error, this way we don't mess up the state of the parser
+				// it also makes parser errors much less noisy
+				_ = p.parseErr(p.previous(), manyDefaults)
+			}
+			_, err = p.consume(token.COLON, "Expect ':' after 'default'")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Default = p.caseBody()
+			continue
+		}
+		if !p.match(token.CASE) {
+			// skip the statements, so the rest of the switch is still parsed
+			_ = p.parseErr(p.peek(), "Expect 'case' or 'default' in switch body")
+			_ = p.caseBody()
+			continue
+		}
+		c := ast.SwitchCase{Keyword: p.previous(), Values: make([]ast.Expr, 0, 1)}
+		for ok := true; ok; ok = p.match(token.COMMA) {
+			val, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			c.Values = append(c.Values, val)
+		}
+		_, err = p.consume(token.COLON, "Expect ':' after case values")
+		if err != nil {
+			return nil, err
+		}
+		c.Body = p.caseBody()
+		stmt.Cases = append(stmt.Cases, c)
+	}
+	_, err = p.consume(token.RBRACE, "Expect '}' after switch body")
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// caseBody parses the statements of a case, up to the next case or the
+// end of the switch
+func (p *Parser) caseBody() []ast.Stmt {
+	stmts := make([]ast.Stmt, 0, 4)
+	for !p.check(token.CASE) && !p.check(token.DEFAULT) && !p.check(token.RBRACE) && !p.isAtEnd() {
+		// errors have already been reported and declaration has synchronised,
+		// so carry on with the rest of the case
+		stmt, _ := p.declaration()
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
 func (p *Parser) forStatement() (ast.Stmt, error) {
+	keyword, label := p.previous(), p.loopLabel()
 	_, err := p.consume(token.LPAREN, "Expect '(' after 'for'")
 	if err != nil {
 		return nil, err
 	}
+	if p.check(token.VAR) && p.cur+2 < len(p.tokens) && p.tokens[p.cur+1].Kind == token.IDENTIFIER &&
+		(p.tokens[p.cur+2].Kind == token.IN || p.tokens[p.cur+2].Kind == token.COMMA) {
+		return p.forInStatement(keyword, label)
+	}
 
 	var init ast.Stmt
 	if p.match(token.SEMICOLON) {
@@ -354,26 +661,17 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	p.loopDepth++
-	defer func() { p.loopDepth-- }()
+	defer p.enterLoop(label)()
 	body, err := p.statement()
 	if err != nil {
 		return nil, err
 	}
 
-	if incr != nil {
-		body = &ast.Block{
-			Statements: []ast.Stmt{
-				body,
-				&ast.Expression{Expression: incr},
-			},
-		}
-	}
-
 	if cond == nil {
 		cond = &ast.Literal{Value: true}
 	}
-	body = &ast.While{Condition: cond, Body: body}
+	// the increment isn't put at the end of the body, so that `continue` still runs it
+	body = &ast.While{Keyword: keyword, Condition: cond, Body: body, Increment: incr, Label: label}
 
 	if init != nil {
 		body = &ast.Block{
@@ -384,7 +682,43 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 	return body, nil
 }
 
+// forInStatement parses the rest of a for-in loop, after the `(`
+func (p *Parser) forInStatement(keyword, label *token.Token) (ast.Stmt, error) {
+	p.advance() // var
+	stmt := &ast.ForIn{Keyword: keyword, Vars: make([]*token.Token, 0, 2), Label: label}
+	for {
+		name, err := p.consume(token.IDENTIFIER, "Expect variable name")
+		if err != nil {
+			return nil, err
+		}
+		stmt.Vars = append(stmt.Vars, name)
+		if len(stmt.Vars) == 2 || !p.match(token.COMMA) {
+			break
+		}
+	}
+	_, err := p.consume(token.IN, "Expect 'in' after loop variables")
+	if err != nil {
+		return nil, err
+	}
+	stmt.Iterable, err = p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(token.RPAREN, "Expect ')' after for-in clause")
+	if err != nil {
+		return nil, err
+	}
+
+	defer p.enterLoop(label)()
+	stmt.Body, err = p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
 func (p *Parser) whileStatement() (ast.Stmt, error) {
+	keyword, label := p.previous(), p.loopLabel()
 	_, err := p.consume(token.LPAREN, "Expect '(' after 'while'")
 	if err != nil {
 		return nil, err
@@ -399,13 +733,12 @@ func (p *Parser) whileStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	p.loopDepth++
-	defer func() { p.loopDepth-- }()
+	defer p.enterLoop(label)()
 	body, err := p.statement()
 	if err != nil {
 		return nil, err
 	}
-	return &ast.While{Condition: cond, Body: body}, nil
+	return &ast.While{Keyword: keyword, Condition: cond, Body: body, Label: label}, nil
 }
 
 func (p *Parser) ifStatement() (ast.Stmt, error) {
@@ -440,12 +773,13 @@ func (p *Parser) ifStatement() (ast.Stmt, error) {
 }
 
 func (p *Parser) block() ([]ast.Stmt, error) {
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
 	stmts := make([]ast.Stmt, 0, 8)
 	for !p.check(token.RBRACE) && !p.isAtEnd() {
-		stmt, err := p.declaration()
-		if err != nil {
-			return nil, err
-		}
+		// errors have already been reported and declaration has synchronised,
+		// so carry on with the rest of the block
+		stmt, _ := p.declaration()
 		stmts = append(stmts, stmt)
 	}
 	_, err := p.consume(token.RBRACE, "Expect '}' after block")
@@ -513,7 +847,7 @@ func (p *Parser) assignment() (ast.Expr, error) {
 			}, nil
 		case *ast.IndexedGet:
 			if n.Stop != nil {
-				_ = p.parseErr(n.Sqr, "Can't use slicing to set values")
+				_ = p.parseErr(n.Sqr, setSlice)
 			}
 			return &ast.IndexedSet{
 				Object: n.Object,
@@ -524,7 +858,7 @@ func (p *Parser) assignment() (ast.Expr, error) {
 		}
 		// only report error, this way we don't mess up the state of the parser
 		// it also makes parser errors much less noisy
-		_ = p.parseErr(opr, "Invalid assignment target")
+		_ = p.parseErr(opr, invalidTarget)
 	}
 	return expr, nil
 }
@@ -550,7 +884,8 @@ func (p *Parser) desugarOprEQ(get ast.Expr, opr *token.Token, val ast.Expr) ast.
 	}
 	// [ (inst.a) + 23 ]
 	// ^Bin     ^Get
-	tok := token.NewToken(oprType, opr.Lexeme, nil, opr.Line)
+	tok := *opr
+	tok.Kind, tok.Lexeme = oprType, strings.TrimSuffix(opr.Lexeme, "=")
 	return &ast.Binary{
 		Left:     get,
 		Operator: &tok,
@@ -613,13 +948,25 @@ func (p *Parser) comparison() (ast.Expr, error) {
 		return nil, err
 	}
 
-	for p.match(token.GT, token.GT_EQ, token.LT, token.LT_EQ) {
+	for p.match(token.GT, token.GT_EQ, token.LT, token.LT_EQ, token.IN, token.NOT) {
 		opr := p.previous()
+		var not *token.Token
+		if opr.Kind == token.NOT {
+			// `a not in b` is `!(a in b)`
+			not = opr
+			opr, err = p.consume(token.IN, "Expect 'in' after 'not'")
+			if err != nil {
+				return nil, err
+			}
+		}
 		rhs, err := p.addition()
 		if err != nil {
 			return nil, err
 		}
 		expr = &ast.Binary{Left: expr, Operator: opr, Right: rhs}
+		if not != nil {
+			expr = &ast.Unary{Operator: not, Right: expr}
+		}
 	}
 	return expr, nil
 }
@@ -704,7 +1051,7 @@ func (p *Parser) finishCall(callee ast.Expr) (ast.Expr, error) {
 			if len(args) >= 255 {
 				// only report error, this way we don't mess up the state of the parser
 				// it also makes parser errors much less noisy
-				_ = p.parseErr(p.peek(), "Can't have more than 255 arguments")
+				_ = p.parseErr(p.peek(), tooManyArgs)
 			}
 			arg, err := p.expression()
 			if err != nil {
@@ -860,8 +1207,8 @@ func (p *Parser) finishArray() ([]ast.Expr, error) {
 	return elements, nil
 }
 
-func (p *Parser) finishHashMap() (map[ast.Expr]ast.Expr, error) {
-	pairs := make(map[ast.Expr]ast.Expr)
+func (p *Parser) finishHashMap() ([]ast.HashPair, error) {
+	pairs := make([]ast.HashPair, 0)
 	if !p.check(token.RBRACE) {
 		for ok := true; ok; ok = p.match(token.COMMA) {
 			// found trailing comma
@@ -888,7 +1235,7 @@ func (p *Parser) finishHashMap() (map[ast.Expr]ast.Expr, error) {
 			if err != nil {
 				return nil, err
 			}
-			pairs[key] = val
+			pairs = append(pairs, ast.HashPair{Key: key, Value: val})
 		}
 	}
 	_, err := p.consume(token.RBRACE, "Expect '}' after array elements")
@@ -906,13 +1253,17 @@ func (p *Parser) consume(kind token.TokenType, msg string) (*token.Token, error)
 }
 
 func (p *Parser) parseErr(tok *token.Token, msg string) error {
-	err := fmt.Errorf("%s", msg)
-	if tok.Kind == token.EOF {
-		fmt.Fprintf(os.Stderr, "[line %d] [Parser] Error at end: %s\n", tok.Line, err)
-	} else {
-		fmt.Fprintf(os.Stderr, "[line %d] [Parser] Error at '%s': %s\n", tok.Line, tok.Lexeme, err)
-	}
-	p.curErr = err
+	code, ok := errCodes[msg]
+	if !ok {
+		code = codeSyntax
+	}
+	err := p.diags.Report(&diag.Diagnostic{
+		Severity: diag.SEV_ERROR,
+		Code:     code,
+		Tok:      tok,
+		Msg:      msg,
+	})
+	p.errs = append(p.errs, err)
 	return err
 }
 
@@ -952,14 +1303,44 @@ func (p *Parser) previous() *token.Token { return &p.tokens[p.cur-1] }
 func (p *Parser) peek() *token.Token     { return &p.tokens[p.cur] }
 func (p *Parser) isAtEnd() bool          { return p.peek().Kind == token.EOF }
 
+// synchroniseMethod skips the rest of a method that failed to parse,
+// stopping at the start of the next method or the end of the class body
+func (p *Parser) synchroniseMethod() {
+	depth := 0
+	for !p.isAtEnd() {
+		switch {
+		case p.check(token.LBRACE):
+			depth++
+		case p.check(token.RBRACE):
+			if depth == 0 {
+				return
+			}
+			depth--
+		case depth == 0 && (p.check(token.STATIC) || p.check(token.IDENTIFIER) && p.checkNext(token.LPAREN)):
+			return
+		}
+		p.advance()
+	}
+}
+
 func (p *Parser) synchronise() {
+	// the error was at the end of the enclosing block, leave the `}` for
+	// the block to consume
+	if p.blockDepth > 0 && p.peek().Kind == token.RBRACE {
+		return
+	}
 	p.advance()
 	for !p.isAtEnd() {
 		if p.previous().Kind == token.SEMICOLON {
 			return
 		}
+		// don't skip past the end of the enclosing block
+		if p.blockDepth > 0 && p.peek().Kind == token.RBRACE {
+			return
+		}
 		switch p.peek().Kind {
-		case token.CLASS, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN, token.BREAK, token.STATIC:
+		case token.CLASS, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN, token.BREAK, token.STATIC,
+			token.TRY, token.THROW, token.IMPORT, token.FROM, token.CONTINUE, token.SWITCH, token.CASE, token.DEFAULT:
 			return
 		}
 		p.advance()
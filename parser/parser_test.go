@@ -0,0 +1,92 @@
+package parser_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/lexer"
+	"github.com/Subarctic2796/gojlox/parser"
+)
+
+// TestRecovery checks which statements are left after the parser recovers
+// from syntax errors, and that each error is only reported once
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		stmts []string
+		errs  []string
+	}{
+		{
+			name:  "error before a block's `}`",
+			src:   "fun f() {\n print\n}\nprint 1;",
+			stmts: []string{"*ast.Function", "*ast.Print"},
+			errs:  []string{"[line 3] Expect expression"},
+		},
+		{
+			name:  "error in a nested block",
+			src:   "{\n {\n  var x = ;\n }\n print 2;\n}\nprint 3;",
+			stmts: []string{"*ast.Block", "*ast.Print"},
+			errs:  []string{"[line 3] Expect expression"},
+		},
+		{
+			name:  "error before a method's `}`",
+			src:   "class A {\n f() {\n  return 1 +\n }\n g() {}\n}\nprint 1;",
+			stmts: []string{"*ast.Class", "*ast.Print"},
+			errs:  []string{"[line 4] Expect expression"},
+		},
+		{
+			name:  "error in a class body",
+			src:   "class A {\n 1;\n}\nprint 1;",
+			stmts: []string{"*ast.Class", "*ast.Print"},
+			errs:  []string{"[line 2] Expect METHOD name"},
+		},
+		{
+			name:  "error in a method's parameters",
+			src:   "class A {\n f( {\n  print 1;\n }\n static g() {}\n}\nprint 1;",
+			stmts: []string{"*ast.Class", "*ast.Print"},
+			errs:  []string{"[line 2] Expect parameter name"},
+		},
+		{
+			name:  "missing `}`",
+			src:   "{\n print 1;\n",
+			stmts: []string{"*ast.BadStmt"},
+			errs:  []string{"[line 3] Expect '}' after block"},
+		},
+		{
+			name:  "several errors",
+			src:   "var = 1;\nprint 2;\nprint (;\nprint 4;",
+			stmts: []string{"*ast.BadStmt", "*ast.Print", "*ast.BadStmt", "*ast.Print"},
+			errs:  []string{"[line 1] Expect variable name", "[line 3] Expect expression"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := diag.NewReporter(nil)
+			toks, err := scanner.NewLexer(tt.src, diags).ScanTokens()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stmts, err := parser.NewParser(toks, diags).Parse()
+			if err == nil {
+				t.Fatal("expected a parse error")
+			}
+			got := make([]string, 0, len(stmts))
+			for _, stmt := range stmts {
+				got = append(got, fmt.Sprintf("%T", stmt))
+			}
+			if !slices.Equal(got, tt.stmts) {
+				t.Errorf("statements:\ngot  %v\nwant %v", got, tt.stmts)
+			}
+			errs := make([]string, 0, len(diags.Diags))
+			for _, d := range diags.Diags {
+				errs = append(errs, fmt.Sprintf("[line %d] %s", d.Tok.Line, d.Msg))
+			}
+			if !slices.Equal(errs, tt.errs) {
+				t.Errorf("errors:\ngot  %q\nwant %q", errs, tt.errs)
+			}
+		})
+	}
+}
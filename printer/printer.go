@@ -0,0 +1,363 @@
+// Package printer renders ast nodes as canonical, Lisp-style s-expressions.
+//
+// The output is stable for a given tree, so it can be used to compare parsed
+// programs in golden tests. Nothing is hidden or re-sugared, so desugared
+// forms like `a.b += 1` show up as the `Set`/`Binary` nodes the parser built.
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/token"
+)
+
+// Fprint writes each statement in stmts to w as an s-expression,
+// one statement per line.
+func Fprint(w io.Writer, stmts []ast.Stmt) error {
+	for _, stmt := range stmts {
+		if _, err := fmt.Fprintln(w, Stmt(stmt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sprint returns the s-expressions of stmts, one statement per line.
+func Sprint(stmts []ast.Stmt) string {
+	var sb strings.Builder
+	_ = Fprint(&sb, stmts)
+	return sb.String()
+}
+
+// Stmt returns the s-expression for a single statement.
+func Stmt(stmt ast.Stmt) string {
+	var p printer
+	p.stmt(stmt)
+	return p.sb.String()
+}
+
+// Expr returns the s-expression for a single expression.
+func Expr(expr ast.Expr) string {
+	var p printer
+	p.expr(expr)
+	return p.sb.String()
+}
+
+type printer struct {
+	sb strings.Builder
+}
+
+// open writes `(name` so that callers only have to write the operands
+// followed by a call to close
+func (p *printer) open(name string) {
+	p.sb.WriteByte('(')
+	p.sb.WriteString(name)
+}
+
+func (p *printer) close() { p.sb.WriteByte(')') }
+
+func (p *printer) atom(s string) {
+	p.sb.WriteByte(' ')
+	p.sb.WriteString(s)
+}
+
+// operand writes a space separated expression, `_` is used for optional
+// expressions that weren't given
+func (p *printer) operand(expr ast.Expr) {
+	p.sb.WriteByte(' ')
+	if expr == nil {
+		p.sb.WriteByte('_')
+		return
+	}
+	p.expr(expr)
+}
+
+func (p *printer) stmts(stmts []ast.Stmt) {
+	for _, s := range stmts {
+		p.sb.WriteByte(' ')
+		p.stmt(s)
+	}
+}
+
+// block writes stmts as a space separated `(block ...)`
+func (p *printer) block(stmts []ast.Stmt) {
+	p.sb.WriteByte(' ')
+	p.open("block")
+	p.stmts(stmts)
+	p.close()
+}
+
+func (p *printer) params(params []*token.Token) {
+	p.sb.WriteString(" (")
+	for i, param := range params {
+		if i != 0 {
+			p.sb.WriteByte(' ')
+		}
+		p.sb.WriteString(param.Lexeme)
+	}
+	p.sb.WriteByte(')')
+}
+
+func (p *printer) literal(val any) {
+	switch v := val.(type) {
+	case nil:
+		p.sb.WriteString("nil")
+	case string:
+		p.sb.WriteString(strconv.Quote(v))
+	case float64:
+		p.sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		p.sb.WriteString(fmt.Sprint(v))
+	}
+}
+
+func (p *printer) function(fn *ast.Function) {
+	switch fn.Kind {
+	case ast.FN_LAMBDA:
+		p.open("fun")
+	case ast.FN_STATIC:
+		p.open("static")
+		p.atom(fn.Name.Lexeme)
+	case ast.FN_METHOD, ast.FN_INIT:
+		p.open("method")
+		p.atom(fn.Name.Lexeme)
+	default:
+		p.open("fun")
+		p.atom(fn.Name.Lexeme)
+	}
+	p.params(fn.Params)
+	p.stmts(fn.Body)
+	p.close()
+}
+
+func (p *printer) expr(exprNode ast.Expr) {
+	switch expr := exprNode.(type) {
+	case *ast.ArrayLiteral:
+		p.open("array")
+		for _, elm := range expr.Elements {
+			p.operand(elm)
+		}
+		p.close()
+	case *ast.Assign:
+		p.open(expr.Operator.Lexeme)
+		p.atom(expr.Name.Lexeme)
+		p.operand(expr.Value)
+		p.close()
+	case *ast.Binary:
+		p.open(expr.Operator.Lexeme)
+		p.operand(expr.Left)
+		p.operand(expr.Right)
+		p.close()
+	case *ast.Call:
+		p.open("call")
+		p.operand(expr.Callee)
+		for _, arg := range expr.Arguments {
+			p.operand(arg)
+		}
+		p.close()
+	case *ast.Get:
+		p.open(".")
+		p.operand(expr.Object)
+		p.atom(expr.Name.Lexeme)
+		p.close()
+	case *ast.Grouping:
+		p.open("group")
+		p.operand(expr.Expression)
+		p.close()
+	case *ast.HashLiteral:
+		p.open("hash")
+		for _, pair := range expr.Pairs {
+			p.sb.WriteString(" (")
+			p.expr(pair.Key)
+			p.operand(pair.Value)
+			p.close()
+		}
+		p.close()
+	case *ast.IndexedGet:
+		if expr.Colon != nil {
+			p.open("slice")
+			p.operand(expr.Object)
+			p.operand(expr.Start)
+			p.operand(expr.Stop)
+		} else {
+			p.open("index")
+			p.operand(expr.Object)
+			p.operand(expr.Start)
+		}
+		p.close()
+	case *ast.IndexedSet:
+		p.open("index=")
+		p.operand(expr.Object)
+		p.operand(expr.Index)
+		p.operand(expr.Value)
+		p.close()
+	case *ast.Lambda:
+		p.function(expr.Func)
+	case *ast.Literal:
+		p.literal(expr.Value)
+	case *ast.Logical:
+		p.open(expr.Operator.Lexeme)
+		p.operand(expr.Left)
+		p.operand(expr.Right)
+		p.close()
+	case *ast.Set:
+		p.open(".=")
+		p.operand(expr.Object)
+		p.atom(expr.Name.Lexeme)
+		p.operand(expr.Value)
+		p.close()
+	case *ast.Super:
+		p.open("super")
+		p.atom(expr.Method.Lexeme)
+		p.close()
+	case *ast.This:
+		p.sb.WriteString("this")
+	case *ast.Unary:
+		p.open(expr.Operator.Lexeme)
+		p.operand(expr.Right)
+		p.close()
+	case *ast.Variable:
+		p.sb.WriteString(expr.Name.Lexeme)
+	default:
+		panic(fmt.Sprintf("printing is not implemented for '%T'", expr))
+	}
+}
+
+func (p *printer) stmt(stmtNode ast.Stmt) {
+	switch stmt := stmtNode.(type) {
+	case *ast.BadStmt:
+		p.open("bad")
+		p.atom(strconv.Quote(stmt.From.Lexeme))
+		p.atom(strconv.Quote(stmt.To.Lexeme))
+		p.close()
+	case *ast.Block:
+		p.open("block")
+		p.stmts(stmt.Statements)
+		p.close()
+	case *ast.Class:
+		p.open("class")
+		p.atom(stmt.Name.Lexeme)
+		if stmt.Superclass != nil {
+			p.sb.WriteString(" (<")
+			p.atom(stmt.Superclass.Name.Lexeme)
+			p.close()
+		}
+		for _, method := range stmt.Methods {
+			p.sb.WriteByte(' ')
+			p.function(method)
+		}
+		p.close()
+	case *ast.Control:
+		p.open(stmt.Keyword.Lexeme)
+		if stmt.Value != nil {
+			p.operand(stmt.Value)
+		}
+		if stmt.Label != nil {
+			p.atom(stmt.Label.Lexeme)
+		}
+		p.close()
+	case *ast.Expression:
+		p.open(";")
+		p.operand(stmt.Expression)
+		p.close()
+	case *ast.Function:
+		p.function(stmt)
+	case *ast.If:
+		p.open("if")
+		p.operand(stmt.Condition)
+		p.sb.WriteByte(' ')
+		p.stmt(stmt.ThenBranch)
+		if stmt.ElseBranch != nil {
+			p.sb.WriteByte(' ')
+			p.stmt(stmt.ElseBranch)
+		}
+		p.close()
+	case *ast.Switch:
+		p.open(stmt.Keyword.Lexeme)
+		p.operand(stmt.Value)
+		for _, c := range stmt.Cases {
+			p.sb.WriteByte(' ')
+			p.open(c.Keyword.Lexeme)
+			for _, val := range c.Values {
+				p.operand(val)
+			}
+			p.block(c.Body)
+			p.close()
+		}
+		if stmt.Default != nil {
+			p.sb.WriteByte(' ')
+			p.open("default")
+			p.block(stmt.Default)
+			p.close()
+		}
+		p.close()
+	case *ast.ForIn:
+		p.open("for-in")
+		if stmt.Label != nil {
+			p.atom(stmt.Label.Lexeme + ":")
+		}
+		p.params(stmt.Vars)
+		p.operand(stmt.Iterable)
+		p.sb.WriteByte(' ')
+		p.stmt(stmt.Body)
+		p.close()
+	case *ast.Import:
+		p.open(stmt.Keyword.Lexeme)
+		p.atom(stmt.Path.Lexeme)
+		if stmt.Name != nil {
+			p.atom(stmt.Name.Lexeme)
+		}
+		for _, name := range stmt.Names {
+			p.atom(name.Lexeme)
+		}
+		p.close()
+	case *ast.Print:
+		p.open("print")
+		p.operand(stmt.Expression)
+		p.close()
+	case *ast.Try:
+		p.open("try")
+		p.block(stmt.Body)
+		if stmt.Catch != nil {
+			p.sb.WriteByte(' ')
+			p.open("catch")
+			if stmt.CatchName != nil {
+				p.atom(stmt.CatchName.Lexeme)
+			}
+			p.block(stmt.Catch)
+			p.close()
+		}
+		if stmt.Finally != nil {
+			p.sb.WriteByte(' ')
+			p.open("finally")
+			p.block(stmt.Finally)
+			p.close()
+		}
+		p.close()
+	case *ast.Var:
+		p.open("var")
+		p.atom(stmt.Name.Lexeme)
+		if stmt.Initializer != nil {
+			p.operand(stmt.Initializer)
+		}
+		p.close()
+	case *ast.While:
+		p.open("while")
+		if stmt.Label != nil {
+			p.atom(stmt.Label.Lexeme + ":")
+		}
+		p.operand(stmt.Condition)
+		p.sb.WriteByte(' ')
+		p.stmt(stmt.Body)
+		if stmt.Increment != nil {
+			p.operand(stmt.Increment)
+		}
+		p.close()
+	default:
+		panic(fmt.Sprintf("printing is not implemented for '%T'", stmt))
+	}
+}
@@ -0,0 +1,72 @@
+package printer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Subarctic2796/gojlox/diag"
+	"github.com/Subarctic2796/gojlox/lexer"
+	"github.com/Subarctic2796/gojlox/parser"
+	"github.com/Subarctic2796/gojlox/printer"
+)
+
+// parse parses src, which may have syntax errors
+func parse(t *testing.T, src string) string {
+	t.Helper()
+	diags := diag.NewReporter(nil)
+	toks, err := scanner.NewLexer(src, diags).ScanTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts, _ := parser.NewParser(toks, diags).Parse()
+	return strings.TrimSuffix(printer.Sprint(stmts), "\n")
+}
+
+func TestSprint(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"literals", `print nil; print true; print 1.5; print "a b";`,
+			"(print nil)\n(print true)\n(print 1.5)\n(print \"a b\")"},
+		{"precedence", `print -1 + 2 * (3 - 4) == !false;`,
+			"(print (== (+ (- 1) (* 2 (group (- 3 4)))) (! false)))"},
+		{"logical", `print a and b or c not in d;`,
+			"(print (or (and a b) (not (in c d))))"},
+		{"var and assign", `var a; var b = 1; a = b = 2;`,
+			"(var a)\n(var b 1)\n(; (= a (= b 2)))"},
+		{"compound assign", `a += 1;`, "(; (+= a 1))"},
+		{"desugared set", `a.b -= 2;`, "(; (.= a b (- (. a b) 2)))"},
+		{"desugared index set", `a[0] *= 3;`, "(; (index= a 0 (* (index a 0) 3)))"},
+		{"index and slices", `print a[1]; print a[1:2]; print a[:2]; print a[1:];`,
+			"(print (index a 1))\n(print (slice a 1 2))\n(print (slice a _ 2))\n(print (slice a 1 _))"},
+		{"array", `print [1, [2], []];`, "(print (array 1 (array 2) (array)))"},
+		{"hashmap keeps source order", `print {"b": 1, "a": 2, 3: {}};`,
+			`(print (hash ("b" 1) ("a" 2) (3 (hash))))`},
+		{"calls and gets", `a.b(1)(c).d;`, "(; (. (call (call (. a b) 1) c) d))"},
+		{"lambda", `var f = fun (x, y) { return x; };`, "(var f (fun (x y) (return x)))"},
+		{"function", `fun f() { print 1; }`, "(fun f () (print 1))"},
+		{"class", `class A < B { init(x) { this.x = x; } static make() { return super.make(); } get() { return this.x; } }`,
+			"(class A (< B) (method init (x) (; (.= this x x))) (static make () (return (call (super make)))) (method get () (return (. this x))))"},
+		{"if", `if (a) print 1; else if (b) print 2; else { print 3; }`,
+			"(if a (print 1) (if b (print 2) (block (print 3))))"},
+		{"for is desugared", `for (var i = 0; i < 3; i += 1) print i;`,
+			"(block (var i 0) (while (< i 3) (print i) (+= i 1)))"},
+		{"labelled loops", `outer: while (true) { for (var x in xs) { continue outer; } break; }`,
+			"(while outer: true (block (for-in (x) xs (block (continue outer))) (break)))"},
+		{"for-in", `for (var k, v in h) print k;`, "(for-in (k v) h (print k))"},
+		{"switch", `switch (x) { case 1, 2: print 1; case 3: break; default: print 0; }`,
+			"(switch x (case 1 2 (block (print 1))) (case 3 (block (break))) (default (block (print 0))))"},
+		{"try", `try { throw Error("e"); } catch (e) { print e; } finally { print 1; }`,
+			`(try (block (throw (call Error "e"))) (catch e (block (print e))) (finally (block (print 1))))`},
+		{"import", `import "lib/a.lox" as a; from "lib/b.lox" import x, y;`,
+			`(import "lib/a.lox" a)` + "\n" + `(from "lib/b.lox" x y)`},
+		{"bad statement", "var = 1;\nprint 2;", "(bad \"var\" \";\")\n(print 2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parse(t, tt.src); got != tt.want {
+				t.Errorf("\ngot  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,9 +3,9 @@ package resolver
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/Subarctic2796/gojlox/ast"
+	"github.com/Subarctic2796/gojlox/diag"
 	"github.com/Subarctic2796/gojlox/interpreter"
 	"github.com/Subarctic2796/gojlox/token"
 )
@@ -16,6 +16,12 @@ var (
 	ErrLocalNotRead         = errors.New("Local variable is not used")
 )
 
+var errCodes = map[error]string{
+	ErrAlreadyInScope:       "S0001",
+	ErrLocalInitializesSelf: "S0002",
+	ErrLocalNotRead:         "S0003",
+}
+
 type varInfo struct {
 	name   *token.Token
 	status varStatus
@@ -34,13 +40,15 @@ type Resolver struct {
 	intprt *interpreter.Interpreter
 	scopes []map[string]*varInfo
 	curErr error
+	diags  *diag.Reporter
 }
 
-func NewResolver(intptr *interpreter.Interpreter) *Resolver {
+func NewResolver(intptr *interpreter.Interpreter, diags *diag.Reporter) *Resolver {
 	return &Resolver{
 		intptr,
 		make([]map[string]*varInfo, 0),
 		nil,
+		diags,
 	}
 }
 
@@ -79,6 +87,12 @@ func (r *Resolver) resolveFunction(fn *ast.Function) {
 	r.endScope()
 }
 
+func (r *Resolver) resolveBlock(stmts []ast.Stmt) {
+	r.beginScope()
+	_ = r.ResolveStmts(stmts)
+	r.endScope()
+}
+
 func (r *Resolver) beginScope() {
 	r.scopes = append(r.scopes, make(map[string]*varInfo))
 }
@@ -111,12 +125,12 @@ func (r *Resolver) define(name *token.Token) {
 }
 
 func (r *Resolver) reportTok(tok *token.Token, msg error) {
-	errfmt := fmt.Sprintf("[line %d] [Resolver] Error at", tok.Line)
-	if tok.Kind == token.EOF {
-		fmt.Fprintf(os.Stderr, "%s end: %s\n", errfmt, msg)
-	} else {
-		fmt.Fprintf(os.Stderr, "%s '%s': %s\n", errfmt, tok.Lexeme, msg)
-	}
+	r.diags.Report(&diag.Diagnostic{
+		Severity: diag.SEV_ERROR,
+		Code:     errCodes[msg],
+		Tok:      tok,
+		Msg:      msg.Error(),
+	})
 	r.curErr = msg
 }
 
@@ -142,9 +156,9 @@ func (r *Resolver) resolveExpr(exprNode ast.Expr) {
 	case *ast.Grouping:
 		r.resolveExpr(expr.Expression)
 	case *ast.HashLiteral:
-		for k, v := range expr.Pairs {
-			r.resolveExpr(k)
-			r.resolveExpr(v)
+		for _, pair := range expr.Pairs {
+			r.resolveExpr(pair.Key)
+			r.resolveExpr(pair.Value)
 		}
 	case *ast.IndexedGet:
 		r.resolveExpr(expr.Object)
@@ -189,10 +203,10 @@ func (r *Resolver) resolveExpr(exprNode ast.Expr) {
 
 func (r *Resolver) resolveStmt(stmtNode ast.Stmt) {
 	switch stmt := stmtNode.(type) {
+	case *ast.BadStmt:
+		// the parser has already reported it
 	case *ast.Block:
-		r.beginScope()
-		_ = r.ResolveStmts(stmt.Statements)
-		r.endScope()
+		r.resolveBlock(stmt.Statements)
 	case *ast.Class:
 		r.declare(stmt.Name)
 		r.define(stmt.Name)
@@ -222,13 +236,59 @@ func (r *Resolver) resolveStmt(stmtNode ast.Stmt) {
 		if stmt.ElseBranch != nil {
 			r.resolveStmt(stmt.ElseBranch)
 		}
+	case *ast.ForIn:
+		r.resolveExpr(stmt.Iterable)
+		r.beginScope()
+		for _, name := range stmt.Vars {
+			r.declare(name)
+			r.define(name)
+			// loop variables don't have to be used, the loop may only want
+			// the keys or only run the body once per element
+			r.scopes[len(r.scopes)-1][name.Lexeme].status = vs_IMPLICIT
+		}
+		r.resolveStmt(stmt.Body)
+		r.endScope()
+	case *ast.Import:
+		if stmt.Name != nil {
+			r.declare(stmt.Name)
+			r.define(stmt.Name)
+		}
+		for _, name := range stmt.Names {
+			r.declare(name)
+			r.define(name)
+		}
 	case *ast.Print:
 		r.resolveExpr(stmt.Expression)
 	case *ast.Control:
-		if stmt.Keyword.Kind == token.RETURN && stmt.Value != nil {
+		if stmt.Value != nil {
 			r.resolveExpr(stmt.Value)
 		}
 		return
+	case *ast.Switch:
+		r.resolveExpr(stmt.Value)
+		for _, c := range stmt.Cases {
+			for _, val := range c.Values {
+				r.resolveExpr(val)
+			}
+			r.resolveBlock(c.Body)
+		}
+		if stmt.Default != nil {
+			r.resolveBlock(stmt.Default)
+		}
+	case *ast.Try:
+		r.resolveBlock(stmt.Body)
+		if stmt.Catch != nil {
+			r.beginScope()
+			if stmt.CatchName != nil {
+				r.declare(stmt.CatchName)
+				r.define(stmt.CatchName)
+			}
+			_ = r.ResolveStmts(stmt.Catch)
+			r.endScope()
+		}
+		if stmt.Finally != nil {
+			r.resolveBlock(stmt.Finally)
+		}
 	case *ast.Var:
 		r.declare(stmt.Name)
 		if stmt.Initializer != nil {
@@ -238,6 +298,9 @@ func (r *Resolver) resolveStmt(stmtNode ast.Stmt) {
 	case *ast.While:
 		r.resolveExpr(stmt.Condition)
 		r.resolveStmt(stmt.Body)
+		if stmt.Increment != nil {
+			r.resolveExpr(stmt.Increment)
+		}
 	default:
 		panic(fmt.Sprintf("resolving is not implemented for '%T'", stmt))
 	}
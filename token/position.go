@@ -0,0 +1,72 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// File is the source that tokens were scanned from.
+// tokens keep a pointer to it so errors can show the offending line
+type File struct {
+	Name string
+	Src  string
+}
+
+// LineText returns the text of the 1 based line n without its newline
+func (f *File) LineText(n int) string {
+	src := f.Src
+	for ; n > 1; n-- {
+		idx := strings.IndexByte(src, '\n')
+		if idx == -1 {
+			return ""
+		}
+		src = src[idx+1:]
+	}
+	if idx := strings.IndexByte(src, '\n'); idx != -1 {
+		src = src[:idx]
+	}
+	return strings.TrimSuffix(src, "\r")
+}
+
+// Pos returns the position of t as `file:line:col`. the file is left out
+// when it doesn't have a name, and the column when it isn't known
+func (t Token) Pos() string {
+	pos := fmt.Sprint(t.Line)
+	if t.Col > 0 {
+		pos = fmt.Sprintf("%d:%d", t.Line, t.Col)
+	}
+	if t.File != nil && t.File.Name != "" {
+		pos = t.File.Name + ":" + pos
+	}
+	return pos
+}
+
+// Underline returns a line of `^` that sits under t when printed below
+// the line of source t starts on. it returns "" if t doesn't know where it came from
+func (t Token) Underline() string {
+	if t.File == nil || t.Col <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	col := 1
+	for _, r := range t.File.LineText(t.Line) {
+		if col >= t.Col {
+			break
+		}
+		// keep tabs so the underline lines up with the source
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+		col++
+	}
+	width := utf8.RuneCountInString(t.Lexeme)
+	if idx := strings.IndexByte(t.Lexeme, '\n'); idx != -1 {
+		// only underline the part on the first line
+		width = utf8.RuneCountInString(strings.TrimSuffix(t.Lexeme[:idx], "\r"))
+	}
+	sb.WriteString(strings.Repeat("^", max(width, 1)))
+	return sb.String()
+}
@@ -6,11 +6,19 @@ type Token struct {
 	Kind    TokenType
 	Lexeme  string
 	Literal any
-	Line    int
+	// line the token starts on
+	Line int
+	// 1 based column the token starts on, counted in characters
+	Col int
+	// byte offsets of the token in File.Src, End is exclusive
+	Start, End int
+	File       *File
 }
 
+// NewToken makes a token that doesn't come from any source.
+// it is used for tokens that are made up by the parser or interpreter
 func NewToken(kind TokenType, lexeme string, lit any, line int) Token {
-	return Token{kind, lexeme, lit, line}
+	return Token{kind, lexeme, lit, line, 0, 0, 0, nil}
 }
 
 func (t Token) String() string {
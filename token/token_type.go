@@ -74,29 +74,58 @@ const (
 	VAR
 	WHILE
 	BREAK
+	CONTINUE
+	TRY
+	CATCH
+	FINALLY
+	THROW
+	IMPORT
+	FROM
+	AS
+	IN
+	NOT
+	SWITCH
+	CASE
+	DEFAULT
+
+	// only produced when the lexer is keeping comments
+	COMMENT
 
 	EOF
 )
 
 var KEYWORDS = map[string]TokenType{
-	"and":    AND,
-	"class":  CLASS,
-	"else":   ELSE,
-	"false":  FALSE,
-	"for":    FOR,
-	"fun":    FUN,
-	"if":     IF,
-	"nil":    NIL,
-	"or":     OR,
-	"print":  PRINT,
-	"return": RETURN,
-	"super":  SUPER,
-	"this":   THIS,
-	"true":   TRUE,
-	"var":    VAR,
-	"while":  WHILE,
-	"break":  BREAK,
-	"static": STATIC,
+	"and":      AND,
+	"class":    CLASS,
+	"else":     ELSE,
+	"false":    FALSE,
+	"for":      FOR,
+	"fun":      FUN,
+	"if":       IF,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"true":     TRUE,
+	"var":      VAR,
+	"while":    WHILE,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"static":   STATIC,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"from":     FROM,
+	"as":       AS,
+	"in":       IN,
+	"not":      NOT,
+	"switch":   SWITCH,
+	"case":     CASE,
+	"default":  DEFAULT,
 }
 
 func LookUpKeyWord(word string) TokenType {
@@ -58,12 +58,26 @@ func _() {
 	_ = x[VAR-47]
 	_ = x[WHILE-48]
 	_ = x[BREAK-49]
-	_ = x[EOF-50]
+	_ = x[CONTINUE-50]
+	_ = x[TRY-51]
+	_ = x[CATCH-52]
+	_ = x[FINALLY-53]
+	_ = x[THROW-54]
+	_ = x[IMPORT-55]
+	_ = x[FROM-56]
+	_ = x[AS-57]
+	_ = x[IN-58]
+	_ = x[NOT-59]
+	_ = x[SWITCH-60]
+	_ = x[CASE-61]
+	_ = x[DEFAULT-62]
+	_ = x[COMMENT-63]
+	_ = x[EOF-64]
 }
 
-const _TokenType_name = "NONELPARENRPARENLBRACERBRACELSQRRSQRCOMMADOTCOLONSEMICOLONBANGNEQEQEQ_EQGTGT_EQLTLT_EQPLUSPLUS_EQMINUSMINUS_EQSLASHSLASH_EQSTARSTAR_EQPERCENTPERCENT_EQIDENTIFIERSTRINGNUMBERANDCLASSELSEFALSEFUNFORIFNILORSTATICPRINTRETURNSUPERTHISTRUEVARWHILEBREAKEOF"
+const _TokenType_name = "NONELPARENRPARENLBRACERBRACELSQRRSQRCOMMADOTCOLONSEMICOLONBANGNEQEQEQ_EQGTGT_EQLTLT_EQPLUSPLUS_EQMINUSMINUS_EQSLASHSLASH_EQSTARSTAR_EQPERCENTPERCENT_EQIDENTIFIERSTRINGNUMBERANDCLASSELSEFALSEFUNFORIFNILORSTATICPRINTRETURNSUPERTHISTRUEVARWHILEBREAKCONTINUETRYCATCHFINALLYTHROWIMPORTFROMASINNOTSWITCHCASEDEFAULTCOMMENTEOF"
 
-var _TokenType_index = [...]uint8{0, 4, 10, 16, 22, 28, 32, 36, 41, 44, 49, 58, 62, 65, 67, 72, 74, 79, 81, 86, 90, 97, 102, 110, 115, 123, 127, 134, 141, 151, 161, 167, 173, 176, 181, 185, 190, 193, 196, 198, 201, 203, 209, 214, 220, 225, 229, 233, 236, 241, 246, 249}
+var _TokenType_index = [...]uint16{0, 4, 10, 16, 22, 28, 32, 36, 41, 44, 49, 58, 62, 65, 67, 72, 74, 79, 81, 86, 90, 97, 102, 110, 115, 123, 127, 134, 141, 151, 161, 167, 173, 176, 181, 185, 190, 193, 196, 198, 201, 203, 209, 214, 220, 225, 229, 233, 236, 241, 246, 254, 257, 262, 269, 274, 280, 284, 286, 288, 291, 297, 301, 308, 315, 318}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {